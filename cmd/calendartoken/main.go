@@ -0,0 +1,40 @@
+// calendartoken выдаёт пользователю токен и ссылку на iCalendar-ленту подписок.
+// Токен подписывается CALENDAR_SECRET, поэтому команда запускается
+// с тем же окружением, что и сервис
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"subscribe_service/internal/config"
+	"subscribe_service/internal/service"
+
+	"github.com/google/uuid"
+)
+
+func main() {
+	envPath := flag.String("env", ".env", "путь к файлу с переменными окружения")
+	userIDStr := flag.String("user", "", "ID пользователя")
+	flag.Parse()
+
+	userID, err := uuid.Parse(*userIDStr)
+	if err != nil {
+		log.Fatalf("invalid -user: %s", err)
+	}
+
+	cfg, err := config.NewConfig(*envPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.CalendarSecret == "" {
+		log.Fatal("CALENDAR_SECRET is not set")
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(service.CalendarToken(cfg.CalendarSecret, userID)); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,54 @@
+// rollupcheck сверяет помесячные начисления из subscription_charges с пересчитанными
+// из subscriptions и завершается с кодом 1 при расхождениях.
+// С флагом -repair таблица перестраивается после сверки
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"subscribe_service/internal/config"
+	repository "subscribe_service/internal/repository/postgres"
+	"time"
+)
+
+func main() {
+	envPath := flag.String("env", ".env", "путь к файлу с переменными окружения")
+	repair := flag.Bool("repair", false, "перестроить начисления при расхождениях")
+	flag.Parse()
+
+	cfg, err := config.NewConfig(*envPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	repo := repository.NewRepository(cfg)
+
+	check, err := repo.CheckRollup()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(check); err != nil {
+		log.Fatal(err)
+	}
+
+	if len(check.Mismatches) == 0 {
+		log.Println("rollup is consistent")
+		return
+	}
+	log.Printf("found %d mismatches\n", len(check.Mismatches))
+
+	if *repair {
+		month := time.Time(*check.Through)
+		if _, err := repo.RefreshRollup(month, true); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("rollup rebuilt through %s\n", month.Format("01-2006"))
+		return
+	}
+
+	os.Exit(1)
+}
@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"container/list"
+	"subscribe_service/internal/entity"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Cache хранит значения по ключу. Каждое значение помечается тегами,
+// по которым его можно удалить, не зная ключа. Реализация должна быть
+// безопасна для параллельного использования
+type Cache interface {
+	Get(key string) (any, bool)
+	Set(key string, value any, tags ...string)
+	// Invalidate удаляет значения, помеченные любым из tags
+	Invalidate(tags ...string)
+	Clear()
+	Stats() entity.CacheStats
+}
+
+type entry struct {
+	key     string
+	value   any
+	tags    []string
+	expires time.Time
+}
+
+// LRU - кеш в памяти процесса ограниченного размера. При переполнении
+// вытесняется давно не читавшееся значение, значения старше ttl не возвращаются
+type LRU struct {
+	size int
+	ttl  time.Duration
+
+	mu    sync.Mutex
+	order *list.List // от недавно использованных к давно использованным
+	items map[string]*list.Element
+	tags  map[string]map[string]struct{} // тег -> ключи
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+func NewLRU(size int, ttl time.Duration) *LRU {
+	return &LRU{
+		size:  size,
+		ttl:   ttl,
+		order: list.New(),
+		items: make(map[string]*list.Element),
+		tags:  make(map[string]map[string]struct{}),
+	}
+}
+
+func (c *LRU) Get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	e := el.Value.(*entry)
+	if time.Now().After(e.expires) {
+		c.remove(el)
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	c.order.MoveToFront(el)
+	c.hits.Add(1)
+	return e.value, true
+}
+
+func (c *LRU) Set(key string, value any, tags ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+
+	el := c.order.PushFront(&entry{key: key, value: value, tags: tags, expires: time.Now().Add(c.ttl)})
+	c.items[key] = el
+	for _, tag := range tags {
+		if c.tags[tag] == nil {
+			c.tags[tag] = make(map[string]struct{})
+		}
+		c.tags[tag][key] = struct{}{}
+	}
+
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+		c.evictions.Add(1)
+	}
+}
+
+func (c *LRU) Invalidate(tags ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range c.tags[tag] {
+			c.remove(c.items[key])
+		}
+	}
+}
+
+func (c *LRU) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.items = make(map[string]*list.Element)
+	c.tags = make(map[string]map[string]struct{})
+}
+
+// Stats возвращает счётчики обращений к кешу с момента запуска
+func (c *LRU) Stats() entity.CacheStats {
+	c.mu.Lock()
+	size := c.order.Len()
+	c.mu.Unlock()
+
+	return entity.CacheStats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+		Size:      size,
+	}
+}
+
+// remove удаляет элемент из списка, индекса ключей и индекса тегов. Вызывается под mu
+func (c *LRU) remove(el *list.Element) {
+	e := c.order.Remove(el).(*entry)
+	delete(c.items, e.key)
+	for _, tag := range e.tags {
+		delete(c.tags[tag], e.key)
+		if len(c.tags[tag]) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+}
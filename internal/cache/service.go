@@ -0,0 +1,306 @@
+package cache
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"strings"
+	"subscribe_service/internal/controller"
+	"subscribe_service/internal/entity"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// tagAll помечает значения без фильтра по пользователю и сервису:
+// их может изменить запись любой подписки
+const tagAll = "all"
+
+// Service кеширует GetRecordByID, GetList и GetTotal сервиса next.
+// Остальные методы передаются в next без изменений. Записи подписок удаляют
+// из кеша значения по её ID, пользователю и сервису до и после изменения,
+// пакетные операции, импорт и изменения каталога и тегов очищают кеш целиком.
+// Изменения, сделанные в обход сервиса (фоновыми задачами или другими экземплярами
+// приложения при внешнем кеше), видны после истечения TTL. То же относится
+// к значению, прочитанному из отстающей реплики сразу после записи
+type Service struct {
+	controller.SubscriptionService
+	cache Cache
+
+	// generation увеличивается при каждой инвалидации. Чтение, во время
+	// которого она изменилась, не сохраняется, чтобы не вернуть в кеш устаревшее значение
+	generation *atomic.Uint64
+	bypassed   *atomic.Uint64
+	// bypass - читать мимо кеша, сохраняя свежий результат
+	bypass bool
+}
+
+func NewService(next controller.SubscriptionService, cache Cache) *Service {
+	return &Service{
+		SubscriptionService: next,
+		cache:               cache,
+		generation:          &atomic.Uint64{},
+		bypassed:            &atomic.Uint64{},
+	}
+}
+
+// Uncached возвращает сервис, который читает мимо кеша и обновляет его результатом.
+// Используется для запросов с Cache-Control: no-cache
+func (s *Service) Uncached() controller.SubscriptionService {
+	bypass := *s
+	bypass.bypass = true
+	return &bypass
+}
+
+func (s *Service) CacheStats() entity.CacheStats {
+	stats := s.cache.Stats()
+	stats.Bypassed = s.bypassed.Load()
+	return stats
+}
+
+func (s *Service) GetRecordByID(id uuid.UUID, asOf *entity.CustomDate, history bool) (*entity.Subscription, error) {
+	key := cacheKey("record", id, asOf, history)
+	return cached(s, key, []string{idTag(id)}, func() (*entity.Subscription, error) {
+		return s.SubscriptionService.GetRecordByID(id, asOf, history)
+	})
+}
+
+func (s *Service) GetList(req *entity.ListRequest) (*entity.SubscriptionsResponse, error) {
+	key := cacheKey("list", req)
+	return cached(s, key, filterTags(req.UserID, req.ServiceName), func() (*entity.SubscriptionsResponse, error) {
+		return s.SubscriptionService.GetList(req)
+	})
+}
+
+func (s *Service) GetTotal(req *entity.TotalRequest) (*entity.TotalResponse, error) {
+	key := cacheKey("total", req)
+	return cached(s, key, filterTags(req.UserID, req.ServiceName), func() (*entity.TotalResponse, error) {
+		return s.SubscriptionService.GetTotal(req)
+	})
+}
+
+func (s *Service) Create(req *entity.CreateRequest) (*entity.Subscription, error) {
+	sub, err := s.SubscriptionService.Create(req)
+	if err == nil {
+		s.invalidate(sub)
+	}
+	return sub, err
+}
+
+func (s *Service) CreateForUser(userID uuid.UUID, req *entity.CreateRequest) (*entity.Subscription, error) {
+	sub, err := s.SubscriptionService.CreateForUser(userID, req)
+	if err == nil {
+		s.invalidate(sub)
+	}
+	return sub, err
+}
+
+func (s *Service) Update(req *entity.Subscription) (*entity.Subscription, error) {
+	old := s.current(req.ID)
+	sub, err := s.SubscriptionService.Update(req)
+	if err == nil {
+		s.invalidate(old, sub)
+	}
+	return sub, err
+}
+
+func (s *Service) Delete(id uuid.UUID) error {
+	old := s.current(id)
+	err := s.SubscriptionService.Delete(id)
+	if err == nil {
+		s.invalidate(old)
+		s.cache.Invalidate(idTag(id))
+	}
+	return err
+}
+
+func (s *Service) Pause(id uuid.UUID, req *entity.PauseRequest) (*entity.Subscription, error) {
+	sub, err := s.SubscriptionService.Pause(id, req)
+	if err == nil {
+		s.invalidate(sub)
+	}
+	return sub, err
+}
+
+func (s *Service) Resume(id uuid.UUID, req *entity.ResumeRequest) (*entity.Subscription, error) {
+	sub, err := s.SubscriptionService.Resume(id, req)
+	if err == nil {
+		s.invalidate(sub)
+	}
+	return sub, err
+}
+
+func (s *Service) SchedulePriceChange(id uuid.UUID, req *entity.PriceChangeRequest) (*entity.Subscription, error) {
+	sub, err := s.SubscriptionService.SchedulePriceChange(id, req)
+	if err == nil {
+		s.invalidate(sub)
+	}
+	return sub, err
+}
+
+func (s *Service) CancelPriceChange(id uuid.UUID, month time.Time) (*entity.Subscription, error) {
+	sub, err := s.SubscriptionService.CancelPriceChange(id, month)
+	if err == nil {
+		s.invalidate(sub)
+	}
+	return sub, err
+}
+
+func (s *Service) Cancel(id uuid.UUID, req *entity.CancelRequest) (*entity.Subscription, error) {
+	sub, err := s.SubscriptionService.Cancel(id, req)
+	if err == nil {
+		s.invalidate(sub)
+	}
+	return sub, err
+}
+
+// пакетные операции и импорт затрагивают произвольный набор подписок,
+// а каталог и теги влияют на фильтры по сервису, категории и тегу,
+// поэтому после них кеш очищается целиком
+
+func (s *Service) Batch(req *entity.BatchRequest) (*entity.BatchResponse, error) {
+	defer s.clear()
+	return s.SubscriptionService.Batch(req)
+}
+
+func (s *Service) Import(r io.Reader, dryRun bool) (*entity.ImportResponse, error) {
+	if !dryRun {
+		defer s.clear()
+	}
+	return s.SubscriptionService.Import(r, dryRun)
+}
+
+func (s *Service) CreateCatalogService(req *entity.CreateCatalogServiceRequest) (*entity.CatalogService, error) {
+	defer s.clear()
+	return s.SubscriptionService.CreateCatalogService(req)
+}
+
+func (s *Service) UpdateCatalogService(svc *entity.CatalogService) (*entity.CatalogService, error) {
+	defer s.clear()
+	return s.SubscriptionService.UpdateCatalogService(svc)
+}
+
+func (s *Service) DeleteCatalogService(id uuid.UUID) error {
+	defer s.clear()
+	return s.SubscriptionService.DeleteCatalogService(id)
+}
+
+func (s *Service) RenameTag(id uuid.UUID, req *entity.TagRequest) (*entity.Tag, error) {
+	defer s.clear()
+	return s.SubscriptionService.RenameTag(id, req)
+}
+
+func (s *Service) DeleteTag(id uuid.UUID) error {
+	defer s.clear()
+	return s.SubscriptionService.DeleteTag(id)
+}
+
+// cached возвращает значение по key из кеша или читает его через read
+// и сохраняет с тегами tags. Ошибки не кешируются
+func cached[T any](s *Service, key string, tags []string, read func() (T, error)) (T, error) {
+	if key == "" {
+		return read()
+	}
+	if s.bypass {
+		s.bypassed.Add(1)
+	} else if v, ok := s.cache.Get(key); ok {
+		return v.(T), nil
+	}
+
+	generation := s.generation.Load()
+	v, err := read()
+	if err != nil {
+		return v, err
+	}
+
+	if s.generation.Load() == generation {
+		s.cache.Set(key, v, tags...)
+	}
+	return v, nil
+}
+
+// current возвращает подписку до изменения, чтобы удалить из кеша значения
+// по её прежним пользователю и сервису. Если подписку прочитать не удалось,
+// запись сама вернёт ошибку
+func (s *Service) current(id uuid.UUID) *entity.Subscription {
+	sub, err := s.SubscriptionService.GetRecordByID(id, nil, false)
+	if err != nil {
+		return nil
+	}
+	return sub
+}
+
+// invalidate удаляет значения, которые могла изменить запись подписок subs
+func (s *Service) invalidate(subs ...*entity.Subscription) {
+	tags := []string{tagAll}
+	for _, sub := range subs {
+		if sub == nil {
+			continue
+		}
+		tags = append(tags, idTag(sub.ID), userTag(sub.UserID))
+		for _, name := range s.serviceNames(sub) {
+			tags = append(tags, serviceTag(name))
+		}
+	}
+
+	s.generation.Add(1)
+	s.cache.Invalidate(tags...)
+}
+
+func (s *Service) clear() {
+	s.generation.Add(1)
+	s.cache.Clear()
+}
+
+// serviceNames возвращает названия, по которым фильтр по сервису находит подписку:
+// её название и, если сервис есть в каталоге, название и псевдонимы из каталога
+func (s *Service) serviceNames(sub *entity.Subscription) []string {
+	names := []string{sub.ServiceName}
+	if sub.ServiceID == uuid.Nil {
+		return names
+	}
+
+	svc, err := s.SubscriptionService.GetCatalogService(sub.ServiceID)
+	if err != nil {
+		return names
+	}
+	return append(append(names, svc.Name), svc.Aliases...)
+}
+
+// filterTags возвращает теги значения, прочитанного с фильтрами userID и serviceName.
+// Результат с фильтром по пользователю может изменить только запись подписки
+// этого пользователя, с фильтром по сервису - только запись подписки на этот сервис
+func filterTags(userID uuid.UUID, serviceName string) []string {
+	switch {
+	case userID != uuid.Nil:
+		return []string{userTag(userID)}
+	case serviceName != "":
+		return []string{serviceTag(serviceName)}
+	default:
+		return []string{tagAll}
+	}
+}
+
+func idTag(id uuid.UUID) string {
+	return "id:" + id.String()
+}
+
+func userTag(id uuid.UUID) string {
+	return "user:" + id.String()
+}
+
+func serviceTag(name string) string {
+	return "service:" + strings.ToLower(name)
+}
+
+// cacheKey строит ключ из названия метода и его аргументов.
+// Пустой ключ означает, что результат не кешируется
+func cacheKey(method string, args ...any) string {
+	b, err := json.Marshal(args)
+	if err != nil {
+		log.Println("building cache key error:", err)
+		return ""
+	}
+	return method + ":" + string(b)
+}
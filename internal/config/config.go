@@ -1,6 +1,9 @@
 package config
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/joho/godotenv"
 )
@@ -12,8 +15,69 @@ type Config struct {
 	DbUser            string `env:"POSTGRES_USER"`
 	DbPassword        string `env:"POSTGRES_PASSWORD"`
 	DbConnectAttempts int    `env:"POSTGRES_CONNECTION_ATTEMPTS"`
+	// DbConnectBackoff - задержка перед второй попыткой подключения, дальше она удваивается до DbConnectBackoffMax
+	DbConnectBackoff    time.Duration `env:"POSTGRES_CONNECT_BACKOFF" env-default:"500ms"`
+	DbConnectBackoffMax time.Duration `env:"POSTGRES_CONNECT_BACKOFF_MAX" env-default:"30s"`
+
+	// DbURL - полная строка подключения, заменяет параметры выше, sslmode, sslrootcert и application_name
+	DbURL             string `env:"DATABASE_URL"`
+	DbSSLMode         string `env:"POSTGRES_SSLMODE" env-default:"disable"`
+	DbSSLRootCert     string `env:"POSTGRES_SSLROOTCERT"`
+	DbApplicationName string `env:"POSTGRES_APPLICATION_NAME" env-default:"subscribe_service"`
+
+	// пул соединений, 0 оставляет значения database/sql по умолчанию
+	DbMaxOpenConns    int           `env:"POSTGRES_MAX_OPEN_CONNS" env-default:"25"`
+	DbMaxIdleConns    int           `env:"POSTGRES_MAX_IDLE_CONNS" env-default:"10"`
+	DbConnMaxLifetime time.Duration `env:"POSTGRES_CONN_MAX_LIFETIME" env-default:"30m"`
+	DbConnMaxIdleTime time.Duration `env:"POSTGRES_CONN_MAX_IDLE_TIME" env-default:"5m"`
+
+	// DbReplicaDSNs - строки подключения к репликам для чтения через запятую
+	DbReplicaDSNs          []string      `env:"POSTGRES_REPLICA_DSNS" env-separator:","`
+	DbReplicaCheckInterval time.Duration `env:"POSTGRES_REPLICA_CHECK_INTERVAL" env-default:"5s"`
+	// ReadYourWritesWindow - сколько после записи клиента читать его запросы
+	// из основной базы, а не из реплик. 0 отключает закрепление
+	ReadYourWritesWindow time.Duration `env:"READ_YOUR_WRITES_WINDOW" env-default:"0s"`
 
 	HttpServerPort string `env:"HttpServerPort"`
+
+	WebhookPollInterval time.Duration `env:"WEBHOOK_POLL_INTERVAL" env-default:"5s"`
+	WebhookTimeout      time.Duration `env:"WEBHOOK_TIMEOUT" env-default:"10s"`
+	WebhookMaxAttempts  int           `env:"WEBHOOK_MAX_ATTEMPTS" env-default:"10"`
+	WebhookBackoffBase  time.Duration `env:"WEBHOOK_BACKOFF_BASE" env-default:"30s"`
+
+	OutboxPublisher    string        `env:"OUTBOX_PUBLISHER" env-default:"log"`
+	OutboxHTTPURL      string        `env:"OUTBOX_HTTP_URL"`
+	OutboxFilePath     string        `env:"OUTBOX_FILE_PATH"`
+	OutboxPollInterval time.Duration `env:"OUTBOX_POLL_INTERVAL" env-default:"1s"`
+	OutboxBatchSize    int           `env:"OUTBOX_BATCH_SIZE" env-default:"100"`
+
+	ReminderTime       string `env:"REMINDER_TIME" env-default:"09:00"`
+	ReminderDaysBefore int    `env:"REMINDER_DAYS_BEFORE" env-default:"7"`
+	ReminderNotifier   string `env:"REMINDER_NOTIFIER" env-default:"log"`
+
+	SMTPAddr     string `env:"SMTP_ADDR"`
+	SMTPUser     string `env:"SMTP_USER"`
+	SMTPPassword string `env:"SMTP_PASSWORD"`
+	SMTPFrom     string `env:"SMTP_FROM"`
+	SMTPTo       string `env:"SMTP_TO"`
+
+	CalendarSecret        string `env:"CALENDAR_SECRET"`
+	CalendarHorizonMonths int    `env:"CALENDAR_HORIZON_MONTHS" env-default:"12"`
+
+	// ServiceCatalogAutoCreate - добавлять ли в каталог неизвестные сервисы при создании подписки
+	ServiceCatalogAutoCreate bool `env:"SERVICE_CATALOG_AUTO_CREATE" env-default:"true"`
+
+	TrialCheckInterval time.Duration `env:"TRIAL_CHECK_INTERVAL" env-default:"1h"`
+
+	// RollupRefreshInterval - как часто проверять, что помесячные начисления заполнены по текущий месяц
+	RollupRefreshInterval time.Duration `env:"ROLLUP_REFRESH_INTERVAL" env-default:"1h"`
+
+	// CacheSize - количество значений в кеше чтения, 0 отключает кеш
+	CacheSize int           `env:"CACHE_SIZE" env-default:"10000"`
+	CacheTTL  time.Duration `env:"CACHE_TTL" env-default:"30s"`
+
+	// BudgetEnforce - отклонять подписки, превышающие бюджет, если клиент не передал allow_over_budget
+	BudgetEnforce bool `env:"BUDGET_ENFORCE" env-default:"false"`
 }
 
 func NewConfig(path ...string) (*Config, error) {
@@ -29,5 +93,10 @@ func NewConfig(path ...string) (*Config, error) {
 		return nil, err
 	}
 
+	// с нулевым интервалом проверка реплик шла бы без остановки
+	if conf.DbReplicaCheckInterval <= 0 {
+		return nil, fmt.Errorf("POSTGRES_REPLICA_CHECK_INTERVAL must be positive, got %s", conf.DbReplicaCheckInterval)
+	}
+
 	return conf, nil
 }
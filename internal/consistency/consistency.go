@@ -0,0 +1,113 @@
+package consistency
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ClientHeader - заголовок, по которому различаются клиенты.
+// Без него клиент определяется по адресу
+const ClientHeader = "X-Client-ID"
+
+type ctxKey struct{}
+
+// UsePrimary сообщает, нужно ли читать запрос из основной базы
+func UsePrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(ctxKey{}).(bool)
+	return primary
+}
+
+// Tracker запоминает время последней успешной записи каждого клиента
+// и в течение window после неё помечает его запросы на чтение из основной базы,
+// чтобы клиент видел свои изменения, пока реплики их не получили
+type Tracker struct {
+	window time.Duration
+
+	mu        sync.Mutex
+	writes    map[string]time.Time
+	lastPrune time.Time
+}
+
+func NewTracker(window time.Duration) *Tracker {
+	return &Tracker{
+		window: window,
+		writes: make(map[string]time.Time),
+	}
+}
+
+func (t *Tracker) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := clientID(r)
+
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			if t.pinned(client) {
+				r = r.WithContext(context.WithValue(r.Context(), ctxKey{}, true))
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+		if sw.status < http.StatusBadRequest {
+			t.record(client)
+		}
+	})
+}
+
+func (t *Tracker) pinned(client string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	at, ok := t.writes[client]
+	return ok && time.Since(at) < t.window
+}
+
+func (t *Tracker) record(client string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	t.writes[client] = now
+
+	// записи старше window больше не влияют на чтение
+	if now.Sub(t.lastPrune) > t.window {
+		for c, at := range t.writes {
+			if now.Sub(at) >= t.window {
+				delete(t.writes, c)
+			}
+		}
+		t.lastPrune = now
+	}
+}
+
+// clientID возвращает ClientHeader или адрес клиента без порта
+func clientID(r *http.Request) string {
+	if id := r.Header.Get(ClientHeader); id != "" {
+		return id
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// statusWriter запоминает код ответа
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap нужен http.ResponseController
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"subscribe_service/internal/entity"
+	"subscribe_service/internal/service"
+)
+
+// GetMRR возвращает ежемесячную регулярную выручку
+//
+// @Summary      MRR
+// @Description  Ежемесячная регулярная выручка за каждый месяц периода и её изменение к предыдущему месяцу. Считается по тем же помесячным начислениям, что и итоги: пробные месяцы и паузы не входят. С by_service=true ряд строится по каждому сервису. Период по умолчанию - последние 12 месяцев, не длиннее 120 месяцев
+// @Tags       	 analytics
+// @Produce      json,application/msgpack
+// @Param        user_id       query  string  false  "User ID"
+// @Param        service_name  query  string  false  "Название сервиса"
+// @Param        tag           query  string  false  "Тег"
+// @Param        category      query  string  false  "Категория сервиса из каталога"
+// @Param        start_date    query  string  false  "Первый месяц (MM-YYYY)"
+// @Param        finish_date   query  string  false  "Последний месяц (MM-YYYY)"
+// @Param        by_service    query  bool    false  "Ряд по каждому сервису"
+// @Success      200  {object} entity.MRRResponse
+// @Failure      400
+// @Failure      500
+// @Router       /analytics/mrr [get]
+func (c *controller) GetMRR(w http.ResponseWriter, r *http.Request) {
+	req, err := parseAnalyticsRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.ByService, err = parseBoolQuery(r, "by_service"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.readService(r).GetMRR(req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Println("getting mrr error:", err)
+		http.Error(w, "can't get mrr", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, resp)
+}
+
+// GetChurnRate возвращает помесячный отток
+//
+// @Summary      Отток
+// @Description  Для каждого месяца периода: подписки, действовавшие на его начало, начавшиеся и закончившиеся в нём, и доля закончившихся среди действовавших на начало. Период по умолчанию - последние 12 месяцев, не длиннее 120 месяцев
+// @Tags       	 analytics
+// @Produce      json,application/msgpack
+// @Param        user_id       query  string  false  "User ID"
+// @Param        service_name  query  string  false  "Название сервиса"
+// @Param        tag           query  string  false  "Тег"
+// @Param        category      query  string  false  "Категория сервиса из каталога"
+// @Param        start_date    query  string  false  "Первый месяц (MM-YYYY)"
+// @Param        finish_date   query  string  false  "Последний месяц (MM-YYYY)"
+// @Success      200  {object} entity.ChurnRateResponse
+// @Failure      400
+// @Failure      500
+// @Router       /analytics/churn [get]
+func (c *controller) GetChurnRate(w http.ResponseWriter, r *http.Request) {
+	req, err := parseAnalyticsRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.readService(r).GetChurnRate(req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Println("getting churn rate error:", err)
+		http.Error(w, "can't get churn rate", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, resp)
+}
+
+// GetCohorts возвращает удержание когорт
+//
+// @Summary      Удержание когорт
+// @Description  Матрица удержания подписок по месяцу начала: для каждой когорты из периода количество и доля подписок, действующих в каждом следующем месяце по finish_date. Период по умолчанию - последние 12 месяцев, не длиннее 120 месяцев
+// @Tags       	 analytics
+// @Produce      json,application/msgpack
+// @Param        user_id       query  string  false  "User ID"
+// @Param        service_name  query  string  false  "Название сервиса"
+// @Param        tag           query  string  false  "Тег"
+// @Param        category      query  string  false  "Категория сервиса из каталога"
+// @Param        start_date    query  string  false  "Первая когорта (MM-YYYY)"
+// @Param        finish_date   query  string  false  "Последняя когорта и последний месяц матрицы (MM-YYYY)"
+// @Success      200  {object} entity.CohortResponse
+// @Failure      400
+// @Failure      500
+// @Router       /analytics/cohorts [get]
+func (c *controller) GetCohorts(w http.ResponseWriter, r *http.Request) {
+	req, err := parseAnalyticsRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.readService(r).GetCohorts(req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Println("getting cohorts error:", err)
+		http.Error(w, "can't get cohorts", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, resp)
+}
+
+// parseAnalyticsRequest читает общие фильтры аналитики из query-параметров
+func parseAnalyticsRequest(r *http.Request) (*entity.AnalyticsRequest, error) {
+	q := r.URL.Query()
+	req := &entity.AnalyticsRequest{
+		ServiceName: q.Get("service_name"),
+		Tag:         q.Get("tag"),
+		Category:    q.Get("category"),
+	}
+
+	var err error
+	if req.UserID, err = parseUUIDQuery(r, "user_id"); err != nil {
+		return nil, err
+	}
+	if req.StartDate, err = parseDateQuery(r, "start_date"); err != nil {
+		return nil, err
+	}
+	if req.FinishDate, err = parseDateQuery(r, "finish_date"); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"subscribe_service/internal/entity"
+	"subscribe_service/internal/service"
+)
+
+// Batch выполняет пакет операций над подписками
+//
+// @Summary      Пакетное создание, обновление и удаление подписок
+// @Description  Выполняет до 1000 операций (create, update, delete) в одной транзакции. В режиме atomic (по умолчанию) при любой ошибке не применяется ни одна операция и возвращается 422, в режиме best_effort применяются все успешные операции. Создания выполняются первыми, затем обновления и удаления в порядке запроса. Обновления и бюджеты проверяются так же, как в одиночных запросах: превышение бюджета при BUDGET_ENFORCE без allow_over_budget отменяет операцию
+// @Tags       	 subscription
+// @Accept       json
+// @Produce		 json,application/msgpack
+// @Param        request  body  entity.BatchRequest  true  "Операции"
+// @Success      200  {object} entity.BatchResponse
+// @Failure      400
+// @Failure      422  {object} entity.BatchResponse
+// @Failure      500
+// @Router       /subscription/batch [post]
+func (c *controller) Batch(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Println("read request body error:", err)
+		http.Error(w, "can't read body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	var req entity.BatchRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		log.Println("unmarshalling error:", err)
+		http.Error(w, "can't unmarshal body", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.service.Batch(&req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Println("batch error:", err)
+		http.Error(w, "can't execute batch", http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusOK
+	if resp.Mode == entity.BatchAtomic && resp.Failed > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+
+	respond(w, r, status, resp)
+}
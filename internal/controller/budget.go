@@ -0,0 +1,238 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"subscribe_service/internal/entity"
+	repository "subscribe_service/internal/repository/postgres"
+	"subscribe_service/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// CreateBudget создаёт бюджет пользователя
+//
+// @Summary      Создание бюджета
+// @Description  Месячный бюджет пользователя на все подписки, на категорию сервисов или на один сервис из каталога. У пользователя может быть один бюджет на каждую категорию и сервис
+// @Tags       	 budget
+// @Accept       json
+// @Produce		 json,application/msgpack
+// @Param        user_id  path  string  true  "User ID"
+// @Param        request  body  entity.BudgetRequest  true  "Budget"
+// @Success      201  {object} entity.Budget
+// @Failure      400
+// @Failure      409
+// @Failure      500
+// @Router       /users/{user_id}/budgets [post]
+func (c *controller) CreateBudget(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "user_id")
+	userID, err := uuid.Parse(idStr)
+	if err != nil {
+		log.Printf("Invalid user_id format: %s. Error: %s\n", idStr, err)
+		http.Error(w, "Invalid user_id format", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Println("read request body error:", err)
+		http.Error(w, "can't read body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	var req entity.BudgetRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		log.Println("unmarshalling error:", err)
+		http.Error(w, "can't unmarshal body", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.service.CreateBudget(userID, &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) || errors.Is(err, repository.ErrIDNotFound) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, repository.ErrConflict) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		log.Println("create budget error:", err)
+		http.Error(w, "can't create budget", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusCreated, resp)
+}
+
+// ListBudgets возвращает бюджеты пользователя
+//
+// @Summary      Бюджеты пользователя
+// @Description  Список бюджетов пользователя
+// @Tags       	 budget
+// @Produce      json,application/msgpack
+// @Param        user_id  path  string  true  "User ID"
+// @Success      200  {object} entity.BudgetsResponse
+// @Failure      400
+// @Failure      500
+// @Router       /users/{user_id}/budgets [get]
+func (c *controller) ListBudgets(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "user_id")
+	userID, err := uuid.Parse(idStr)
+	if err != nil {
+		log.Printf("Invalid user_id format: %s. Error: %s\n", idStr, err)
+		http.Error(w, "Invalid user_id format", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.readService(r).ListBudgets(userID)
+	if err != nil {
+		log.Printf("List budgets error: %s", err)
+		http.Error(w, "Getting budgets error.", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, resp)
+}
+
+// UpdateBudget обновляет бюджет пользователя
+//
+// @Summary      Обновление бюджета
+// @Description  Замена категории, сервиса и суммы бюджета
+// @Tags       	 budget
+// @Accept       json
+// @Produce		 json,application/msgpack
+// @Param        user_id  path  string  true  "User ID"
+// @Param        id       path  string  true  "Budget ID"
+// @Param        request  body  entity.BudgetRequest  true  "Budget"
+// @Success      200  {object} entity.Budget
+// @Failure      400
+// @Failure      409
+// @Failure      500
+// @Router       /users/{user_id}/budgets/{id} [put]
+func (c *controller) UpdateBudget(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := parseBudgetPath(w, r)
+	if !ok {
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Println("read request body error:", err)
+		http.Error(w, "can't read body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	var req entity.BudgetRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		log.Println("unmarshalling error:", err)
+		http.Error(w, "can't unmarshal body", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.service.UpdateBudget(userID, id, &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) || errors.Is(err, repository.ErrIDNotFound) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, repository.ErrConflict) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		log.Println("update budget error:", err)
+		http.Error(w, "can't update budget", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, resp)
+}
+
+// DeleteBudget удаляет бюджет пользователя
+//
+// @Summary      Удаление бюджета
+// @Description  Удаление бюджета по ID
+// @Tags       	 budget
+// @Param        user_id  path  string  true  "User ID"
+// @Param        id       path  string  true  "Budget ID"
+// @Success      204
+// @Failure      400
+// @Failure      500
+// @Router       /users/{user_id}/budgets/{id} [delete]
+func (c *controller) DeleteBudget(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := parseBudgetPath(w, r)
+	if !ok {
+		return
+	}
+
+	err := c.service.DeleteBudget(userID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrIDNotFound) {
+			http.Error(w, fmt.Sprintf("Failed to delete budget. %s: %s", err, id), http.StatusBadRequest)
+			return
+		}
+		log.Printf("failed to delete budget: %s\n", err)
+		http.Error(w, "Failed to delete budget", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetBudgetStatus сравнивает бюджеты пользователя с расходами
+//
+// @Summary      Состояние бюджетов
+// @Description  Расходы пользователя за месяц по каждому бюджету, посчитанные так же, как итоги: без пробных месяцев и месяцев паузы
+// @Tags       	 budget
+// @Produce      json,application/msgpack
+// @Param        user_id  path   string  true   "User ID"
+// @Param        month    query  string  false  "Месяц (MM-YYYY), по умолчанию текущий"
+// @Success      200  {object} entity.BudgetStatusResponse
+// @Failure      400
+// @Failure      500
+// @Router       /users/{user_id}/budget-status [get]
+func (c *controller) GetBudgetStatus(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "user_id")
+	userID, err := uuid.Parse(idStr)
+	if err != nil {
+		log.Printf("Invalid user_id format: %s. Error: %s\n", idStr, err)
+		http.Error(w, "Invalid user_id format", http.StatusBadRequest)
+		return
+	}
+
+	month, err := parseDateQuery(r, "month")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.readService(r).GetBudgetStatus(userID, month)
+	if err != nil {
+		log.Println("getting budget status error:", err)
+		http.Error(w, "can't get budget status", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, resp)
+}
+
+// parseBudgetPath читает user_id и id бюджета из пути и при ошибке отвечает 400
+func parseBudgetPath(w http.ResponseWriter, r *http.Request) (userID, id uuid.UUID, ok bool) {
+	idStr := chi.URLParam(r, "user_id")
+	userID, err := uuid.Parse(idStr)
+	if err != nil {
+		log.Printf("Invalid user_id format: %s. Error: %s\n", idStr, err)
+		http.Error(w, "Invalid user_id format", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	id, ok = parseIDParam(w, r)
+	return userID, id, ok
+}
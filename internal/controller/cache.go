@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+	"subscribe_service/internal/consistency"
+	"subscribe_service/internal/entity"
+)
+
+// cachedService - сервис с кешем чтения (см. cache.Service)
+type cachedService interface {
+	// Uncached возвращает сервис, читающий мимо кеша
+	Uncached() SubscriptionService
+	CacheStats() entity.CacheStats
+}
+
+// readService возвращает сервис для чтения. Через него идут все обработчики,
+// которые только читают, чтобы клиент видел свои записи. Запрос, закреплённый
+// за основной базой после записи клиента, читается через primary, запрос
+// с Cache-Control: no-cache читает мимо кеша
+func (c *controller) readService(r *http.Request) SubscriptionService {
+	if consistency.UsePrimary(r.Context()) {
+		return c.primary
+	}
+
+	cached, ok := c.service.(cachedService)
+	if !ok {
+		return c.service
+	}
+
+	for _, directive := range strings.Split(r.Header.Get("Cache-Control"), ",") {
+		if strings.EqualFold(strings.TrimSpace(directive), "no-cache") {
+			return cached.Uncached()
+		}
+	}
+	return c.service
+}
+
+// GetCacheStats возвращает счётчики кеша чтения
+//
+// @Summary      Статистика кеша
+// @Description  Попадания, промахи, вытеснения и обходы кеша GetRecordByID, списка подписок и итогов с момента запуска. Если кеш отключён, возвращается 404
+// @Tags       	 cache
+// @Produce      json,application/msgpack
+// @Success      200  {object} entity.CacheStats
+// @Failure      404
+// @Router       /cache/stats [get]
+func (c *controller) GetCacheStats(w http.ResponseWriter, r *http.Request) {
+	cached, ok := c.service.(cachedService)
+	if !ok {
+		http.Error(w, "cache is disabled", http.StatusNotFound)
+		return
+	}
+
+	respond(w, r, http.StatusOK, cached.CacheStats())
+}
@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"subscribe_service/internal/service"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// Calendar возвращает iCalendar-ленту подписок пользователя
+//
+// @Summary      Календарь продлений и окончаний подписок
+// @Description  VCALENDAR (RFC 5545) с событием на каждое ближайшее продление и окончание подписок пользователя. Доступ по токену, выданному командой calendartoken
+// @Tags       	 calendar
+// @Produce      text/calendar
+// @Param        user_id   path      string  true  "User ID"
+// @Param        token     query     string  true  "Токен доступа"
+// @Success      200  {file} file
+// @Failure      400
+// @Failure      403
+// @Failure      500
+// @Router       /users/{user_id}/subscriptions.ics [get]
+func (c *controller) Calendar(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "user_id")
+	userID, err := uuid.Parse(idStr)
+	if err != nil {
+		log.Printf("Invalid user_id format: %s. Error: %s\n", idStr, err)
+		http.Error(w, "Invalid user_id format", http.StatusBadRequest)
+		return
+	}
+
+	cal, err := c.readService(r).GetCalendar(userID, r.URL.Query().Get("token"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidToken) {
+			http.Error(w, "Invalid token", http.StatusForbidden)
+			return
+		}
+		log.Println("getting calendar error:", err)
+		http.Error(w, "can't get calendar", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar;charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="subscriptions.ics"`)
+	w.Write(cal.Marshal(time.Now()))
+}
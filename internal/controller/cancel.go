@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"subscribe_service/internal/entity"
+	repository "subscribe_service/internal/repository/postgres"
+	"subscribe_service/internal/service"
+)
+
+// Cancel отменяет подписку
+//
+// @Summary      Отмена подписки
+// @Description  Отмена подписки с указанием причины. effective=immediately оставляет подписку действующей по текущий день, end_of_month - по последний день текущего месяца, month - по последний день месяца month. День записывается в cancellation.effective_date, а finish_date становится месяц окончания: оплата помесячная, поэтому immediately и end_of_month оплачивают текущий месяц одинаково. Нельзя отменить уже закончившуюся или отменённую подписку, а также перенести окончание на более поздний месяц. Паузы после окончания удаляются. Отправляется событие subscription.cancelled
+// @Tags       	 subscription
+// @Accept       json
+// @Produce		 json,application/msgpack
+// @Param        id       path  string  true  "Subscription ID"
+// @Param        request  body  entity.CancelRequest  true  "Отмена"
+// @Success      200  {object} entity.Subscription
+// @Failure      400
+// @Failure      500
+// @Router       /subscription/{id}/cancel [post]
+func (c *controller) Cancel(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Println("read request body error:", err)
+		http.Error(w, "can't read body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	var req entity.CancelRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		log.Println("unmarshalling error:", err)
+		http.Error(w, "can't unmarshal body", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.service.Cancel(id, &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) || errors.Is(err, repository.ErrIDNotFound) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Println("cancel subscription error:", err)
+		http.Error(w, "can't cancel subscription", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, resp)
+}
+
+// GetChurn возвращает отчёт об оттоке
+//
+// @Summary      Отчёт об оттоке
+// @Description  Количество подписок, отменённых через cancel, и сумма их ежемесячных платежей по месяцу окончания, причине и сервису. start_date и finish_date ограничивают месяцы окончания
+// @Tags       	 total
+// @Produce      json,application/msgpack
+// @Param        start_date    query  string  false  "Первый месяц (MM-YYYY)"
+// @Param        finish_date   query  string  false  "Последний месяц (MM-YYYY)"
+// @Param        service_name  query  string  false  "Service name"
+// @Param        user_id       query  string  false  "User ID"
+// @Success      200  {object} entity.ChurnResponse
+// @Failure      400
+// @Failure      500
+// @Router       /subscription/churn [get]
+func (c *controller) GetChurn(w http.ResponseWriter, r *http.Request) {
+	req := &entity.ChurnRequest{
+		ServiceName: r.URL.Query().Get("service_name"),
+	}
+
+	var err error
+	if req.UserID, err = parseUUIDQuery(r, "user_id"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.StartDate, err = parseDateQuery(r, "start_date"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.FinishDate, err = parseDateQuery(r, "finish_date"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.readService(r).GetChurn(req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Println("getting churn error:", err)
+		http.Error(w, "can't get churn report", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, resp)
+}
@@ -0,0 +1,220 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"subscribe_service/internal/entity"
+	repository "subscribe_service/internal/repository/postgres"
+	"subscribe_service/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// CreateCatalogService добавляет сервис в каталог
+//
+// @Summary      Добавление сервиса в каталог
+// @Description  Добавление сервиса с каноническим названием и псевдонимами. Название и псевдонимы не должны совпадать с названиями и псевдонимами других сервисов без учёта регистра
+// @Tags       	 service
+// @Accept       json
+// @Produce		 json,application/msgpack
+// @Param        request  body  entity.CreateCatalogServiceRequest  true  "Service"
+// @Success      201  {object} entity.CatalogService
+// @Failure      400
+// @Failure      409
+// @Failure      500
+// @Router       /services [post]
+func (c *controller) CreateCatalogService(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Println("read request body error:", err)
+		http.Error(w, "can't read body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	var req entity.CreateCatalogServiceRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		log.Println("unmarshalling error:", err)
+		http.Error(w, "can't unmarshal body", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.service.CreateCatalogService(&req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, repository.ErrConflict) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		log.Println("create service error:", err)
+		http.Error(w, "can't create service", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusCreated, resp)
+}
+
+// ListCatalogServices возвращает каталог сервисов
+//
+// @Summary      Каталог сервисов
+// @Description  Список сервисов каталога, отсортированный по названию
+// @Tags       	 service
+// @Produce      json,application/msgpack,text/csv
+// @Param        category  query  string  false  "Категория"
+// @Success      200  {object} entity.CatalogServicesResponse
+// @Failure      500
+// @Router       /services [get]
+func (c *controller) ListCatalogServices(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.readService(r).ListCatalogServices(r.URL.Query().Get("category"))
+	if err != nil {
+		log.Printf("List services error: %s", err)
+		http.Error(w, "Getting services error.", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, resp)
+}
+
+// GetCatalogService возвращает сервис каталога по ID
+//
+// @Summary      Получение сервиса каталога
+// @Description  Получение сервиса каталога по ID
+// @Tags       	 service
+// @Produce		 json,application/msgpack
+// @Param        id   path      string  true  "Service ID"
+// @Success      200  {object} entity.CatalogService
+// @Failure      400
+// @Failure      500
+// @Router       /services/{id} [get]
+func (c *controller) GetCatalogService(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		log.Printf("Invalid ID format: %s. Error: %s\n", idStr, err)
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.readService(r).GetCatalogService(id)
+	if err != nil {
+		if errors.Is(err, repository.ErrIDNotFound) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Failed to get service: %s. id: %s\n", err, id)
+		http.Error(w, "Failed to get service", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, resp)
+}
+
+// UpdateCatalogService обновляет сервис каталога
+//
+// @Summary      Обновление сервиса каталога
+// @Description  Полное обновление сервиса каталога. Новое название переносится во все подписки сервиса
+// @Tags       	 service
+// @Accept       json
+// @Produce      json,application/msgpack
+// @Param        id       path  string  true  "Service ID"
+// @Param        request  body  entity.CreateCatalogServiceRequest  true  "Service"
+// @Success      200  {object} entity.CatalogService
+// @Failure      400
+// @Failure      409
+// @Failure      500
+// @Router       /services/{id} [put]
+func (c *controller) UpdateCatalogService(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		log.Printf("Invalid ID format: %s. Error: %s\n", idStr, err)
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Println("read request body error:", err)
+		http.Error(w, "can't read body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	var req entity.CreateCatalogServiceRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		log.Println("unmarshalling error:", err)
+		http.Error(w, "can't unmarshal body", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.service.UpdateCatalogService(&entity.CatalogService{
+		ID:           id,
+		Name:         req.Name,
+		Aliases:      req.Aliases,
+		Category:     req.Category,
+		DefaultPrice: req.DefaultPrice,
+		Currency:     req.Currency,
+		Website:      req.Website,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) || errors.Is(err, repository.ErrIDNotFound) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, repository.ErrConflict) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		log.Println("update service error:", err)
+		http.Error(w, "can't update service", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, resp)
+}
+
+// DeleteCatalogService удаляет сервис из каталога
+//
+// @Summary      Удаление сервиса каталога
+// @Description  Удаление сервиса по ID. Сервис, на который ссылаются подписки, удалить нельзя
+// @Tags       	 service
+// @Param        id   path      string  true  "Service ID"
+// @Success      204
+// @Failure      400
+// @Failure      409
+// @Failure      500
+// @Router       /services/{id} [delete]
+func (c *controller) DeleteCatalogService(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		log.Printf("Invalid ID format: %s. Error: %s\n", idStr, err)
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	err = c.service.DeleteCatalogService(id)
+	if err != nil {
+		if errors.Is(err, repository.ErrIDNotFound) {
+			http.Error(w, fmt.Sprintf("Failed to delete service. %s: %s", err, id), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, repository.ErrConflict) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		log.Printf("failed to delete service: %s\n", err)
+		http.Error(w, "Failed to delete service", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -6,10 +6,11 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"subscribe_service/internal/config"
 	"subscribe_service/internal/entity"
+	"subscribe_service/internal/ical"
 	repository "subscribe_service/internal/repository/postgres"
 	"subscribe_service/internal/service"
+	"time"
 
 	"log"
 
@@ -19,34 +20,81 @@ import (
 
 type SubscriptionService interface {
 	Create(req *entity.CreateRequest) (*entity.Subscription, error)
-	GetRecordByID(id uuid.UUID) (*entity.Subscription, error)
+	GetRecordByID(id uuid.UUID, asOf *entity.CustomDate, history bool) (*entity.Subscription, error)
 	Update(req *entity.Subscription) (*entity.Subscription, error)
 	Delete(id uuid.UUID) error
-	GetList() (*entity.SubscriptionsResponse, error)
+	GetList(req *entity.ListRequest) (*entity.SubscriptionsResponse, error)
 
 	GetTotal(req *entity.TotalRequest) (*entity.TotalResponse, error)
+	GetForecast(userID uuid.UUID, serviceName string, months int) (*entity.ForecastResponse, error)
+	GetMRR(req *entity.AnalyticsRequest) (*entity.MRRResponse, error)
+	GetChurnRate(req *entity.AnalyticsRequest) (*entity.ChurnRateResponse, error)
+	GetCohorts(req *entity.AnalyticsRequest) (*entity.CohortResponse, error)
+	GetUpcoming(userID uuid.UUID, serviceName string, within time.Duration) (*entity.UpcomingResponse, error)
+	Batch(req *entity.BatchRequest) (*entity.BatchResponse, error)
+	Import(r io.Reader, dryRun bool) (*entity.ImportResponse, error)
+	Export(req *entity.ListRequest, fn func(sub *entity.Subscription) error) error
+	Search(req *entity.SearchRequest) (*entity.SearchResponse, error)
+	GetTrialsEnding(userID uuid.UUID, within time.Duration) (*entity.TrialsResponse, error)
+	Pause(id uuid.UUID, req *entity.PauseRequest) (*entity.Subscription, error)
+	Resume(id uuid.UUID, req *entity.ResumeRequest) (*entity.Subscription, error)
+	SchedulePriceChange(id uuid.UUID, req *entity.PriceChangeRequest) (*entity.Subscription, error)
+	CancelPriceChange(id uuid.UUID, month time.Time) (*entity.Subscription, error)
+	Cancel(id uuid.UUID, req *entity.CancelRequest) (*entity.Subscription, error)
+	GetChurn(req *entity.ChurnRequest) (*entity.ChurnResponse, error)
+	CreateForUser(userID uuid.UUID, req *entity.CreateRequest) (*entity.Subscription, error)
+	GetUserSummary(userID uuid.UUID) (*entity.UserSummary, error)
+	GetCalendar(userID uuid.UUID, token string) (*ical.Calendar, error)
+
+	CreateWebhook(req *entity.CreateWebhookRequest) (*entity.WebhookEndpoint, error)
+	ListWebhooks() (*entity.WebhookEndpointsResponse, error)
+	DeleteWebhook(id uuid.UUID) error
+	ListWebhookDeliveries(req *entity.WebhookDeliveriesRequest) (*entity.WebhookDeliveriesResponse, error)
+	RedeliverWebhook(id uuid.UUID) error
+
+	CreateCatalogService(req *entity.CreateCatalogServiceRequest) (*entity.CatalogService, error)
+	GetCatalogService(id uuid.UUID) (*entity.CatalogService, error)
+	ListCatalogServices(category string) (*entity.CatalogServicesResponse, error)
+	UpdateCatalogService(svc *entity.CatalogService) (*entity.CatalogService, error)
+	DeleteCatalogService(id uuid.UUID) error
+
+	CreateTag(req *entity.TagRequest) (*entity.Tag, error)
+	ListTags() (*entity.TagsResponse, error)
+	RenameTag(id uuid.UUID, req *entity.TagRequest) (*entity.Tag, error)
+	DeleteTag(id uuid.UUID) error
+
+	CreateBudget(userID uuid.UUID, req *entity.BudgetRequest) (*entity.Budget, error)
+	ListBudgets(userID uuid.UUID) (*entity.BudgetsResponse, error)
+	UpdateBudget(userID, id uuid.UUID, req *entity.BudgetRequest) (*entity.Budget, error)
+	DeleteBudget(userID, id uuid.UUID) error
+	GetBudgetStatus(userID uuid.UUID, month *entity.CustomDate) (*entity.BudgetStatusResponse, error)
 }
 
 type controller struct {
 	service SubscriptionService
+	// primary читает из основной базы в обход кеша и реплик,
+	// для запросов, закреплённых за ней после записи клиента
+	primary SubscriptionService
 }
 
-func NewController(cfg *config.Config) *controller {
+func NewController(s, primary SubscriptionService) *controller {
 	return &controller{
-		service: service.NewService(cfg),
+		service: s,
+		primary: primary,
 	}
 }
 
 // Create создаёт новую подписку
 //
 // @Summary      Создание новой подписки
-// @Description  Создание новой подписки
+// @Description  Создание новой подписки. Сервис задаётся service_id или service_name: название ищется в каталоге без учёта регистра и по псевдонимам, неизвестное название добавляется в каталог, если это разрешено настройкой SERVICE_CATALOG_AUTO_CREATE. Если подписка выводит расходы пользователя за месячный бюджет, отправляется событие budget.exceeded, а при BUDGET_ENFORCE=true без allow_over_budget возвращается 409
 // @Tags       	 subscription
 // @Accept       json
-// @Produce		 json
+// @Produce		 json,application/msgpack
 // @Param        request  body  entity.CreateRequest  true  "DataForCreate"
 // @Success      201  {object} entity.Subscription
 // @Failure      400
+// @Failure      409
 // @Failure      500
 // @Router       /subscription [post]
 func (c *controller) Create(w http.ResponseWriter, r *http.Request) {
@@ -67,30 +115,32 @@ func (c *controller) Create(w http.ResponseWriter, r *http.Request) {
 
 	resp, err := c.service.Create(&req)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) || errors.Is(err, repository.ErrIDNotFound) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, service.ErrBudgetExceeded) || errors.Is(err, repository.ErrConflict) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		log.Println("create subscription error:", err)
 		http.Error(w, "can't create subscription", http.StatusInternalServerError)
 		return
 	}
 
-	jsonResp, err := json.Marshal(resp)
-	if err != nil {
-		log.Println("marshalling response error:", err)
-		http.Error(w, "can't marshal response", http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json;charset=utf-8")
-	w.WriteHeader(http.StatusCreated)
-	w.Write(jsonResp)
+	respond(w, r, http.StatusCreated, resp)
 }
 
 // GetRecordByID возвращает данные подписки по ID
 //
 // @Summary     Получение данных о подписке
-// @Description Получение данных о подписке
+// @Description Получение данных о подписке. Статус вычисляется на текущий месяц или на месяц as_of. С history=true подписка возвращается в том виде, в каком была на конец месяца as_of по истории изменений. Если на тот момент подписки ещё не было или она уже была удалена, возвращается 400
 // @Tags       	subscription
-// @Produce		json
-// @Param        id   path      string  true  "Subscription ID"
+// @Produce		json,application/msgpack
+// @Param        id       path      string  true  "Subscription ID"
+// @Param        as_of    query     string  false  "Месяц, на который вычисляется статус (MM-YYYY)"
+// @Param        history  query     bool    false  "Вернуть значения на конец месяца as_of"
+// @Param        Cache-Control  header  string  false  "no-cache - прочитать мимо кеша"
 // @Success    	200  {object} entity.Subscription
 // @Failure    	400
 // @Failure    	500
@@ -104,34 +154,43 @@ func (c *controller) GetRecordByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := c.service.GetRecordByID(id)
+	asOf, err := parseDateQuery(r, "as_of")
 	if err != nil {
-		log.Printf("Failed to get record: %s. id: %s\n", err, id)
-		http.Error(w, "Failed to get record", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	history, err := parseBoolQuery(r, "history")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	data, err := json.Marshal(resp)
+	resp, err := c.readService(r).GetRecordByID(id, asOf, history)
 	if err != nil {
-		log.Println("marshalling error:", err)
-		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		if errors.Is(err, service.ErrInvalidRequest) || errors.Is(err, repository.ErrIDNotFound) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Failed to get record: %s. id: %s\n", err, id)
+		http.Error(w, "Failed to get record", http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json;charset=utf-8")
-	w.Write(data)
+	respond(w, r, http.StatusOK, resp)
 }
 
 // Update обновляет данные подписки
 //
 // @Summary      Обновление данных подписки
-// @Description  Обновление данных подписки
+// @Description  Обновление данных подписки. Статус подписки должен меняться допустимым образом. Если подписка выводит расходы пользователя за месячный бюджет, отправляется событие budget.exceeded, а при BUDGET_ENFORCE=true без allow_over_budget возвращается 409
 // @Tags       	 subscription
 // @Accept       json
-// @Produce      json
+// @Produce      json,application/msgpack
 // @Param        request  body  entity.Subscription  true  "Data for update"
 // @Success      200  {object} entity.Subscription
 // @Failure      400
+// @Failure      409
 // @Failure      500
 // @Router       /subscription [put]
 func (c *controller) Update(w http.ResponseWriter, r *http.Request) {
@@ -152,21 +211,20 @@ func (c *controller) Update(w http.ResponseWriter, r *http.Request) {
 
 	resp, err := c.service.Update(&req)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) || errors.Is(err, repository.ErrIDNotFound) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, service.ErrBudgetExceeded) || errors.Is(err, repository.ErrConflict) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		log.Println("create subscription error:", err)
 		http.Error(w, "can't create subscription", http.StatusInternalServerError)
 		return
 	}
 
-	jsonResp, err := json.Marshal(resp)
-	if err != nil {
-		log.Println("marshalling response error:", err)
-		http.Error(w, "can't marshal response", http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json;charset=utf-8")
-	w.WriteHeader(http.StatusOK)
-	w.Write(jsonResp)
+	respond(w, r, http.StatusOK, resp)
 }
 
 // Delete удаляет подписку по ID
@@ -203,41 +261,56 @@ func (c *controller) Delete(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// List возвращает список всех подписок
+// List возвращает список подписок
 //
 // @Summary      Получение списка подписок
-// @Description  Получение списка всех подписок
+// @Description  Получение списка подписок с учётом фильтров. Все фильтры опциональны
 // @Tags       	 subscription
-// @Produce      json
+// @Produce      json,application/msgpack,text/csv
+// @Param        user_id       query  string  false  "User ID"
+// @Param        service_name  query  string  false  "Название сервиса"
+// @Param        start_date    query  string  false  "Подписки, начавшиеся не раньше (MM-YYYY)"
+// @Param        finish_date   query  string  false  "Подписки, закончившиеся не позже (MM-YYYY)"
+// @Param        tag           query  string  false  "Тег"
+// @Param        category      query  string  false  "Категория сервиса из каталога"
+// @Param        status        query  string  false  "Статус на месяц as_of" Enums(scheduled,trialing,active,paused,cancelled,expired)
+// @Param        as_of         query  string  false  "Подписки, действовавшие в месяце (MM-YYYY). Статус вычисляется на этот месяц, по умолчанию на текущий"
+// @Param        history       query  bool    false  "Вернуть значения подписок на конец месяца as_of по истории изменений"
+// @Param        Cache-Control  header  string  false  "no-cache - прочитать мимо кеша"
 // @Success      200  {object} entity.SubscriptionsResponse
+// @Failure      400
 // @Failure      500
 // @Router       /subscription [get]
 func (c *controller) List(w http.ResponseWriter, r *http.Request) {
-	resp, err := c.service.GetList()
+	req, err := parseListRequest(r)
 	if err != nil {
-		log.Printf("List error: %s", err)
-		http.Error(w, "Getting list error.", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	data, err := json.Marshal(resp)
+	resp, err := c.readService(r).GetList(req)
 	if err != nil {
-		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		if errors.Is(err, service.ErrInvalidRequest) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("List error: %s", err)
+		http.Error(w, "Getting list error.", http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json;charset=utf-8")
-	w.Write(data)
+	respond(w, r, http.StatusOK, resp)
 }
 
 // GetTotal возвращает общую стоимость и количество подписок
 //
 // @Summary      Получение общей стоимости и количества подписок
-// @Description  Получение общей стоимости и количества подписок с учётом фильтров.
+// @Description  Сумма помесячных списаний за период с start_date по finish_date включительно (по умолчанию с начала подписок по текущий месяц) и количество подписок, действовавших в периоде. НЕСОВМЕСТИМОЕ ИЗМЕНЕНИЕ: раньше total был суммой цен подписок, пересекающихся с периодом, без учёта числа месяцев. Теперь подписка начисляется за каждый месяц периода по цене этого месяца с учётом смен цены, месяцы пробного периода и пауз не оплачиваются, годовая подписка начисляется только в месяц оплаты. Итог за несколько месяцев поэтому больше суммы цен подписок (см. README). as_of задаёт период из одного месяца. С group_by=tag или group_by=category дополнительно возвращаются итоги по тегам или категориям
 // @Tags       	 total
 // @Accept       json
-// @Produce      json
+// @Produce      json,application/msgpack
 // @Param        request  body  entity.TotalRequest  true  "Фильтры для статистики. Все поля опциональны"
+// @Param        Cache-Control  header  string  false  "no-cache - прочитать мимо кеша"
 // @Success      200  {object} entity.TotalResponse
 // @Failure      400
 // @Failure      500
@@ -258,21 +331,16 @@ func (c *controller) GetTotal(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := c.service.GetTotal(&req)
+	resp, err := c.readService(r).GetTotal(&req)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		log.Println("getting total error:", err)
 		http.Error(w, "can't get total", http.StatusInternalServerError)
 		return
 	}
 
-	jsonResp, err := json.Marshal(resp)
-	if err != nil {
-		log.Println("marshalling response error:", err)
-		http.Error(w, "can't marshal response", http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json;charset=utf-8")
-	w.WriteHeader(http.StatusOK)
-	w.Write(jsonResp)
+	respond(w, r, http.StatusOK, resp)
 }
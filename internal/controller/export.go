@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"subscribe_service/internal/entity"
+	"subscribe_service/internal/export"
+	"time"
+)
+
+// Export выгружает подписки в файл
+//
+// @Summary      Выгрузка подписок
+// @Description  Потоковая выгрузка подписок в CSV, JSON Lines или XLSX с теми же фильтрами, что и у списка подписок
+// @Tags       	 subscription
+// @Produce      text/csv
+// @Produce      application/jsonl
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param        format        query  string  false  "csv (по умолчанию), jsonl или xlsx"
+// @Param        user_id       query  string  false  "User ID"
+// @Param        service_name  query  string  false  "Название сервиса"
+// @Param        start_date    query  string  false  "Подписки, начавшиеся не раньше (MM-YYYY)"
+// @Param        finish_date   query  string  false  "Подписки, закончившиеся не позже (MM-YYYY)"
+// @Param        tag           query  string  false  "Тег"
+// @Param        category      query  string  false  "Категория сервиса из каталога"
+// @Param        status        query  string  false  "Статус на месяц as_of" Enums(scheduled,trialing,active,paused,cancelled,expired)
+// @Param        as_of         query  string  false  "Подписки, действовавшие в месяце (MM-YYYY). Статус вычисляется на этот месяц, по умолчанию на текущий"
+// @Success      200  {file} file
+// @Failure      400
+// @Failure      500
+// @Router       /subscription/export [get]
+func (c *controller) Export(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = export.FormatCSV
+	}
+	if format != export.FormatCSV && format != export.FormatJSONL && format != export.FormatXLSX {
+		http.Error(w, "Invalid format. Supported formats: csv, jsonl, xlsx", http.StatusBadRequest)
+		return
+	}
+
+	req, err := parseListRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.History {
+		http.Error(w, "history isn't supported by export", http.StatusBadRequest)
+		return
+	}
+
+	// выгрузка большой таблицы может длиться дольше WriteTimeout сервера
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		log.Println("reset write deadline error:", err)
+	}
+
+	filename := fmt.Sprintf("subscriptions-%s.%s", time.Now().Format("2006-01-02"), format)
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	writer, err := export.NewWriter(format, w)
+	if err != nil {
+		log.Println("creating export writer error:", err)
+		http.Error(w, "can't export subscriptions", http.StatusInternalServerError)
+		return
+	}
+
+	// после начала записи статус уже не изменить, поэтому ошибка только логируется
+	// и обрывает выгрузку: клиент получит неполный файл без завершающей части
+	err = c.readService(r).Export(req, func(sub *entity.Subscription) error {
+		return writer.Write(sub)
+	})
+	if err != nil {
+		log.Println("export error:", err)
+		return
+	}
+
+	if err := writer.Close(); err != nil {
+		log.Println("closing export writer error:", err)
+	}
+}
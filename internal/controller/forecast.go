@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"subscribe_service/internal/service"
+)
+
+// GetForecast возвращает прогноз расходов
+//
+// @Summary      Прогноз расходов
+// @Description  Ожидаемые списания за каждый месяц начиная с текущего и накопленный итог. Бессрочные подписки продлеваются до конца прогноза, известные даты окончания, пробные периоды, паузы, периоды оплаты и запланированные смены цены учитываются так же, как в итогах: годовая подписка даёт списание только в месяц оплаты
+// @Tags       	 total
+// @Produce      json,application/msgpack,text/csv
+// @Param        user_id       query  string  false  "User ID"
+// @Param        service_name  query  string  false  "Название сервиса"
+// @Param        months        query  int     false  "Количество месяцев, по умолчанию 12, не больше 120"
+// @Success      200  {object} entity.ForecastResponse
+// @Failure      400
+// @Failure      500
+// @Router       /subscription/forecast [get]
+func (c *controller) GetForecast(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseUUIDQuery(r, "user_id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var months int
+	if s := r.URL.Query().Get("months"); s != "" {
+		months, err = strconv.Atoi(s)
+		if err != nil {
+			http.Error(w, "Invalid months format", http.StatusBadRequest)
+			return
+		}
+	}
+
+	resp, err := c.readService(r).GetForecast(userID, r.URL.Query().Get("service_name"), months)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Println("getting forecast error:", err)
+		http.Error(w, "can't get forecast", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, resp)
+}
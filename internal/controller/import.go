@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"errors"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	repository "subscribe_service/internal/repository/postgres"
+	"subscribe_service/internal/service"
+	"time"
+)
+
+// Import загружает подписки из CSV
+//
+// @Summary      Импорт подписок из CSV
+// @Description  Принимает CSV с заголовком user_id, service_name, price, start_date, finish_date, trial_end_date, billing_period (последние три колонки необязательны, даты в форматах MM-YYYY, YYYY-MM-DD или RFC3339, billing_period - monthly или yearly). Файл читается потоково, каждая строка проверяется. С dry_run=true возвращает только ошибки по строкам, иначе загружает корректные строки. Строки, совпадающие с существующими подписками, пропускаются. Если импорт выводит расходы пользователя за бюджет, пишется событие budget.exceeded, а при BUDGET_ENFORCE импорт отменяется с 409
+// @Tags       	 subscription
+// @Accept       text/csv
+// @Produce		 json,application/msgpack
+// @Param        dry_run  query  bool  false  "Только проверить файл"
+// @Success      200  {object} entity.ImportResponse
+// @Failure      400
+// @Failure      409
+// @Failure      415
+// @Failure      500
+// @Router       /subscription/import [post]
+func (c *controller) Import(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "text/csv" {
+		http.Error(w, "Content-Type must be text/csv", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	// большой файл читается дольше таймаутов сервера, поэтому для этого
+	// запроса они снимаются
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Println("import read deadline error:", err)
+	}
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Println("import write deadline error:", err)
+	}
+
+	var dryRun bool
+	if s := r.URL.Query().Get("dry_run"); s != "" {
+		dryRun, err = strconv.ParseBool(s)
+		if err != nil {
+			http.Error(w, "Invalid dry_run format", http.StatusBadRequest)
+			return
+		}
+	}
+
+	resp, err := c.service.Import(r.Body, dryRun)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, service.ErrBudgetExceeded) || errors.Is(err, repository.ErrConflict) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		log.Println("import error:", err)
+		http.Error(w, "can't import subscriptions", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, resp)
+}
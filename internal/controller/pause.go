@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"subscribe_service/internal/entity"
+	repository "subscribe_service/internal/repository/postgres"
+	"subscribe_service/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// Pause приостанавливает оплату подписки
+//
+// @Summary      Приостановка подписки
+// @Description  Приостанавливает оплату с месяца start_date (по умолчанию текущего) по end_date включительно. Без end_date пауза действует до возобновления. Паузы не должны пересекаться и выходить за даты подписки. Месяцы паузы не входят в итоги
+// @Tags       	 subscription
+// @Accept       json
+// @Produce		 json,application/msgpack
+// @Param        id       path  string  true   "Subscription ID"
+// @Param        request  body  entity.PauseRequest  false  "Пауза"
+// @Success      200  {object} entity.Subscription
+// @Failure      400
+// @Failure      500
+// @Router       /subscription/{id}/pause [post]
+func (c *controller) Pause(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req entity.PauseRequest
+	if !readOptionalJSON(w, r, &req) {
+		return
+	}
+
+	resp, err := c.service.Pause(id, &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) || errors.Is(err, repository.ErrIDNotFound) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Println("pause subscription error:", err)
+		http.Error(w, "can't pause subscription", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, resp)
+}
+
+// Resume возобновляет оплату подписки
+//
+// @Summary      Возобновление подписки
+// @Description  Возобновляет оплату с месяца date (по умолчанию текущего). Пауза, действующая в этом месяце, заканчивается предыдущим месяцем
+// @Tags       	 subscription
+// @Accept       json
+// @Produce		 json,application/msgpack
+// @Param        id       path  string  true   "Subscription ID"
+// @Param        request  body  entity.ResumeRequest  false  "Месяц возобновления"
+// @Success      200  {object} entity.Subscription
+// @Failure      400
+// @Failure      500
+// @Router       /subscription/{id}/resume [post]
+func (c *controller) Resume(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req entity.ResumeRequest
+	if !readOptionalJSON(w, r, &req) {
+		return
+	}
+
+	resp, err := c.service.Resume(id, &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) || errors.Is(err, repository.ErrIDNotFound) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Println("resume subscription error:", err)
+		http.Error(w, "can't resume subscription", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, resp)
+}
+
+// parseIDParam читает ID из пути и при ошибке отвечает 400
+func parseIDParam(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		log.Printf("Invalid ID format: %s. Error: %s\n", idStr, err)
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// readOptionalJSON разбирает тело запроса в v, если оно не пустое, и при ошибке отвечает 400
+func readOptionalJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Println("read request body error:", err)
+		http.Error(w, "can't read body", http.StatusBadRequest)
+		return false
+	}
+	defer r.Body.Close()
+
+	if len(body) == 0 {
+		return true
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		log.Println("unmarshalling error:", err)
+		http.Error(w, "can't unmarshal body", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
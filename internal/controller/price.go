@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"subscribe_service/internal/entity"
+	repository "subscribe_service/internal/repository/postgres"
+	"subscribe_service/internal/service"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// SchedulePriceChange планирует смену цены подписки
+//
+// @Summary      Смена цены подписки
+// @Description  Планирует новую цену с месяца effective_date, не раньше текущего и не позже окончания подписки. Смена в том же месяце заменяется. Новая цена учитывается в итогах, прогнозе и ближайших платежах начиная с этого месяца
+// @Tags       	 subscription
+// @Accept       json
+// @Produce		 json,application/msgpack
+// @Param        id       path  string  true  "Subscription ID"
+// @Param        request  body  entity.PriceChangeRequest  true  "Смена цены"
+// @Success      200  {object} entity.Subscription
+// @Failure      400
+// @Failure      500
+// @Router       /subscription/{id}/price-changes [post]
+func (c *controller) SchedulePriceChange(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req entity.PriceChangeRequest
+	if !readOptionalJSON(w, r, &req) {
+		return
+	}
+
+	resp, err := c.service.SchedulePriceChange(id, &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) || errors.Is(err, repository.ErrIDNotFound) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Println("schedule price change error:", err)
+		http.Error(w, "can't schedule price change", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, resp)
+}
+
+// CancelPriceChange отменяет запланированную смену цены
+//
+// @Summary      Отмена смены цены
+// @Description  Удаляет смену цены с месяца month (MM-YYYY). Смены прошлых месяцев уже действуют и не отменяются
+// @Tags       	 subscription
+// @Produce		 json,application/msgpack
+// @Param        id     path  string  true  "Subscription ID"
+// @Param        month  path  string  true  "Месяц смены, MM-YYYY"
+// @Success      200  {object} entity.Subscription
+// @Failure      400
+// @Failure      500
+// @Router       /subscription/{id}/price-changes/{month} [delete]
+func (c *controller) CancelPriceChange(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	month, err := entity.ParseCustomDate(chi.URLParam(r, "month"))
+	if err != nil {
+		http.Error(w, "Invalid month format", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.service.CancelPriceChange(id, time.Time(month))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) || errors.Is(err, repository.ErrIDNotFound) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Println("cancel price change error:", err)
+		http.Error(w, "can't cancel price change", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, resp)
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"subscribe_service/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+// parseListRequest читает фильтры списка подписок из query-параметров
+func parseListRequest(r *http.Request) (*entity.ListRequest, error) {
+	query := r.URL.Query()
+	req := &entity.ListRequest{
+		ServiceName: query.Get("service_name"),
+		Tag:         query.Get("tag"),
+		Category:    query.Get("category"),
+		Status:      query.Get("status"),
+	}
+
+	if req.Status != "" && !slices.Contains(entity.Statuses, req.Status) {
+		return nil, fmt.Errorf("Invalid status. Supported statuses: %s", strings.Join(entity.Statuses, ", "))
+	}
+
+	var err error
+	if req.UserID, err = parseUUIDQuery(r, "user_id"); err != nil {
+		return nil, err
+	}
+	if req.StartDate, err = parseDateQuery(r, "start_date"); err != nil {
+		return nil, err
+	}
+	if req.FinishDate, err = parseDateQuery(r, "finish_date"); err != nil {
+		return nil, err
+	}
+	if req.AsOf, err = parseDateQuery(r, "as_of"); err != nil {
+		return nil, err
+	}
+	if req.History, err = parseBoolQuery(r, "history"); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// parseUUIDQuery возвращает uuid.Nil, если параметр не передан
+func parseUUIDQuery(r *http.Request, name string) (uuid.UUID, error) {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return uuid.Nil, nil
+	}
+
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("Invalid %s format", name)
+	}
+	return id, nil
+}
+
+// parseDateQuery возвращает nil, если параметр не передан
+func parseDateQuery(r *http.Request, name string) (*entity.CustomDate, error) {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return nil, nil
+	}
+
+	date, err := entity.ParseCustomDate(s)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid %s format", name)
+	}
+	return &date, nil
+}
+
+// parseBoolQuery возвращает false, если параметр не передан
+func parseBoolQuery(r *http.Request, name string) (bool, error) {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return false, nil
+	}
+
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, fmt.Errorf("Invalid %s format", name)
+	}
+	return v, nil
+}
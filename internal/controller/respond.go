@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"log"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"subscribe_service/internal/msgpack"
+)
+
+const (
+	mediaJSON    = "application/json"
+	mediaCSV     = "text/csv"
+	mediaMsgPack = "application/msgpack"
+)
+
+// mediaAliases - другие распространённые названия поддерживаемых типов
+var mediaAliases = map[string]string{
+	"application/x-msgpack":   mediaMsgPack,
+	"application/vnd.msgpack": mediaMsgPack,
+}
+
+// csvTable реализуют ответы-списки, которые можно отдать в CSV
+type csvTable interface {
+	CSV() (header []string, records [][]string)
+}
+
+// respond кодирует v в формат из заголовка Accept и пишет ответ со статусом status.
+// По умолчанию используется JSON, CSV доступен только для ответов-списков.
+// Если ни один из запрошенных форматов не поддерживается, возвращает 406
+func respond(w http.ResponseWriter, r *http.Request, status int, v any) {
+	_, isTable := v.(csvTable)
+	media, ok := negotiate(r.Header.Get("Accept"), isTable)
+	if !ok {
+		http.Error(w, "Not acceptable. Supported media types: application/json, application/msgpack, text/csv (lists only)", http.StatusNotAcceptable)
+		return
+	}
+
+	var data []byte
+	var contentType string
+	var err error
+	switch media {
+	case mediaCSV:
+		data, err = encodeCSV(v.(csvTable))
+		contentType = "text/csv;charset=utf-8"
+	case mediaMsgPack:
+		data, err = msgpack.Marshal(v)
+		contentType = mediaMsgPack
+	default:
+		data, err = json.Marshal(v)
+		contentType = "application/json;charset=utf-8"
+	}
+	if err != nil {
+		log.Printf("encoding %s response error: %s\n", media, err)
+		http.Error(w, "can't encode response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// negotiate выбирает формат ответа по заголовку Accept с учётом q-весов
+func negotiate(accept string, isTable bool) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return mediaJSON, true
+	}
+
+	type mediaRange struct {
+		media string
+		q     float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		media, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if s, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(s, 64); err != nil {
+				continue
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+
+		if alias, ok := mediaAliases[media]; ok {
+			media = alias
+		}
+		ranges = append(ranges, mediaRange{media: media, q: q})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	for _, mr := range ranges {
+		switch mr.media {
+		case mediaJSON, "application/*", "*/*":
+			return mediaJSON, true
+		case mediaMsgPack:
+			return mediaMsgPack, true
+		case mediaCSV, "text/*":
+			if isTable {
+				return mediaCSV, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+func encodeCSV(t csvTable) ([]byte, error) {
+	header, records := t.CSV()
+
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	if err := cw.Write(header); err != nil {
+		return nil, err
+	}
+	if err := cw.WriteAll(records); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"subscribe_service/internal/entity"
+	"subscribe_service/internal/service"
+)
+
+// Search ищет подписки по названию сервиса
+//
+// @Summary      Поиск подписок
+// @Description  Нечёткий и полнотекстовый поиск подписок по части названия сервиса, в том числе с опечатками и по псевдонимам из каталога. Результаты отсортированы по релевантности, совпавшие слова в highlight обёрнуты в <mark>
+// @Tags       	 subscription
+// @Produce      json,application/msgpack
+// @Param        q        query  string  true   "Строка поиска"
+// @Param        user_id  query  string  false  "User ID"
+// @Param        limit    query  int     false  "Размер страницы, по умолчанию 20, не больше 100"
+// @Param        offset   query  int     false  "Смещение"
+// @Success      200  {object} entity.SearchResponse
+// @Failure      400
+// @Failure      500
+// @Router       /subscription/search [get]
+func (c *controller) Search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	req := &entity.SearchRequest{Query: query.Get("q")}
+
+	var err error
+	if req.UserID, err = parseUUIDQuery(r, "user_id"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if s := query.Get("limit"); s != "" {
+		if req.Limit, err = strconv.Atoi(s); err != nil {
+			http.Error(w, "Invalid limit format", http.StatusBadRequest)
+			return
+		}
+	}
+	if s := query.Get("offset"); s != "" {
+		if req.Offset, err = strconv.Atoi(s); err != nil {
+			http.Error(w, "Invalid offset format", http.StatusBadRequest)
+			return
+		}
+	}
+
+	resp, err := c.readService(r).Search(req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Println("search error:", err)
+		http.Error(w, "can't search subscriptions", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, resp)
+}
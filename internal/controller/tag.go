@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"subscribe_service/internal/entity"
+	repository "subscribe_service/internal/repository/postgres"
+	"subscribe_service/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// CreateTag создаёт тег
+//
+// @Summary      Создание тега
+// @Description  Создание тега. Теги уникальны без учёта регистра. Теги, переданные в подписке, создаются автоматически
+// @Tags       	 tag
+// @Accept       json
+// @Produce		 json,application/msgpack
+// @Param        request  body  entity.TagRequest  true  "Tag"
+// @Success      201  {object} entity.Tag
+// @Failure      400
+// @Failure      409
+// @Failure      500
+// @Router       /tags [post]
+func (c *controller) CreateTag(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Println("read request body error:", err)
+		http.Error(w, "can't read body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	var req entity.TagRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		log.Println("unmarshalling error:", err)
+		http.Error(w, "can't unmarshal body", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.service.CreateTag(&req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, repository.ErrConflict) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		log.Println("create tag error:", err)
+		http.Error(w, "can't create tag", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusCreated, resp)
+}
+
+// ListTags возвращает все теги
+//
+// @Summary      Список тегов
+// @Description  Список тегов с количеством подписок у каждого
+// @Tags       	 tag
+// @Produce      json,application/msgpack
+// @Success      200  {object} entity.TagsResponse
+// @Failure      500
+// @Router       /tags [get]
+func (c *controller) ListTags(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.readService(r).ListTags()
+	if err != nil {
+		log.Printf("List tags error: %s", err)
+		http.Error(w, "Getting tags error.", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, resp)
+}
+
+// RenameTag переименовывает тег
+//
+// @Summary      Переименование тега
+// @Description  Переименование тега во всех подписках
+// @Tags       	 tag
+// @Accept       json
+// @Produce		 json,application/msgpack
+// @Param        id       path  string  true  "Tag ID"
+// @Param        request  body  entity.TagRequest  true  "Tag"
+// @Success      200  {object} entity.Tag
+// @Failure      400
+// @Failure      409
+// @Failure      500
+// @Router       /tags/{id} [put]
+func (c *controller) RenameTag(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		log.Printf("Invalid ID format: %s. Error: %s\n", idStr, err)
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Println("read request body error:", err)
+		http.Error(w, "can't read body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	var req entity.TagRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		log.Println("unmarshalling error:", err)
+		http.Error(w, "can't unmarshal body", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.service.RenameTag(id, &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) || errors.Is(err, repository.ErrIDNotFound) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, repository.ErrConflict) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		log.Println("rename tag error:", err)
+		http.Error(w, "can't rename tag", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, resp)
+}
+
+// DeleteTag удаляет тег
+//
+// @Summary      Удаление тега
+// @Description  Удаление тега по ID. Тег снимается со всех подписок
+// @Tags       	 tag
+// @Param        id   path      string  true  "Tag ID"
+// @Success      204
+// @Failure      400
+// @Failure      500
+// @Router       /tags/{id} [delete]
+func (c *controller) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		log.Printf("Invalid ID format: %s. Error: %s\n", idStr, err)
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	err = c.service.DeleteTag(id)
+	if err != nil {
+		if errors.Is(err, repository.ErrIDNotFound) {
+			http.Error(w, fmt.Sprintf("Failed to delete tag. %s: %s", err, id), http.StatusBadRequest)
+			return
+		}
+		log.Printf("failed to delete tag: %s\n", err)
+		http.Error(w, "Failed to delete tag", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
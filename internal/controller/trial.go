@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+const defaultTrialsWindow = 7 * 24 * time.Hour
+
+// GetTrialsEnding возвращает подписки, пробный период которых скоро закончится
+//
+// @Summary      Заканчивающиеся пробные периоды
+// @Description  Подписки, у которых пробный период заканчивается в окне от текущей даты и которые не отменены до его окончания. После окончания пробного периода отправляется событие subscription.trial_converted
+// @Tags       	 subscription
+// @Produce      json,application/msgpack,text/csv
+// @Param        within   query  string  false  "Окно от текущей даты: 7d, 2w или длительность Go (72h). По умолчанию 7d"
+// @Param        user_id  query  string  false  "User ID"
+// @Success      200  {object} entity.TrialsResponse
+// @Failure      400
+// @Failure      500
+// @Router       /subscription/trials [get]
+func (c *controller) GetTrialsEnding(w http.ResponseWriter, r *http.Request) {
+	within := defaultTrialsWindow
+	if s := r.URL.Query().Get("within"); s != "" {
+		var err error
+		within, err = parseWindow(s)
+		if err != nil {
+			log.Printf("Invalid within: %s. Error: %s\n", s, err)
+			http.Error(w, "Invalid within format", http.StatusBadRequest)
+			return
+		}
+	}
+
+	userID, err := parseUUIDQuery(r, "user_id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.readService(r).GetTrialsEnding(userID, within)
+	if err != nil {
+		log.Println("getting trials error:", err)
+		http.Error(w, "can't get trials", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, resp)
+}
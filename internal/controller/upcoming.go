@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const defaultUpcomingWindow = 30 * 24 * time.Hour
+
+// GetUpcoming возвращает подписки, которые скоро истекают или продлеваются
+//
+// @Summary      Ближайшие продления и окончания подписок
+// @Description  Подписки, последний день месяца finish_date которых попадает в окно (expiring, event_date - этот день), и бессрочные подписки, у которых в окно попадает следующая дата списания (renewing)
+// @Tags       	 subscription
+// @Produce      json,application/msgpack,text/csv
+// @Param        within        query  string  false  "Окно от текущей даты: 30d, 2w или длительность Go (72h). По умолчанию 30d"
+// @Param        user_id       query  string  false  "User ID"
+// @Param        service_name  query  string  false  "Название сервиса"
+// @Success      200  {object} entity.UpcomingResponse
+// @Failure      400
+// @Failure      500
+// @Router       /subscription/upcoming [get]
+func (c *controller) GetUpcoming(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	within := defaultUpcomingWindow
+	if s := query.Get("within"); s != "" {
+		var err error
+		within, err = parseWindow(s)
+		if err != nil {
+			log.Printf("Invalid within: %s. Error: %s\n", s, err)
+			http.Error(w, "Invalid within format", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var userID uuid.UUID
+	if s := query.Get("user_id"); s != "" {
+		var err error
+		userID, err = uuid.Parse(s)
+		if err != nil {
+			log.Printf("Invalid user_id format: %s. Error: %s\n", s, err)
+			http.Error(w, "Invalid user_id format", http.StatusBadRequest)
+			return
+		}
+	}
+
+	resp, err := c.readService(r).GetUpcoming(userID, query.Get("service_name"), within)
+	if err != nil {
+		log.Println("getting upcoming error:", err)
+		http.Error(w, "can't get upcoming subscriptions", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, resp)
+}
+
+// parseWindow разбирает длительность окна. Помимо формата time.ParseDuration
+// поддерживаются дни (30d) и недели (2w)
+func parseWindow(s string) (time.Duration, error) {
+	var unit time.Duration
+	switch {
+	case strings.HasSuffix(s, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(s, "w"):
+		unit = 7 * 24 * time.Hour
+	default:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, err
+		}
+		if d <= 0 {
+			return 0, fmt.Errorf("window must be positive: %s", s)
+		}
+		return d, nil
+	}
+
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil {
+		return 0, fmt.Errorf("invalid window %s: %w", s, err)
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("window must be positive: %s", s)
+	}
+
+	return time.Duration(n) * unit, nil
+}
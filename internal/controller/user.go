@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"subscribe_service/internal/entity"
+	repository "subscribe_service/internal/repository/postgres"
+	"subscribe_service/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// ListUserSubscriptions возвращает подписки пользователя
+//
+// @Summary      Подписки пользователя
+// @Description  Список подписок пользователя. Принимает те же фильтры, что и список подписок, кроме user_id
+// @Tags       	 user
+// @Produce      json,application/msgpack,text/csv
+// @Param        user_id       path   string  true   "User ID"
+// @Param        service_name  query  string  false  "Название сервиса"
+// @Param        start_date    query  string  false  "Подписки, начавшиеся не раньше (MM-YYYY)"
+// @Param        finish_date   query  string  false  "Подписки, закончившиеся не позже (MM-YYYY)"
+// @Param        tag           query  string  false  "Тег"
+// @Param        category      query  string  false  "Категория сервиса из каталога"
+// @Param        status        query  string  false  "Статус на месяц as_of" Enums(scheduled,trialing,active,paused,cancelled,expired)
+// @Param        as_of         query  string  false  "Подписки, действовавшие в месяце (MM-YYYY). Статус вычисляется на этот месяц, по умолчанию на текущий"
+// @Param        history       query  bool    false  "Вернуть значения подписок на конец месяца as_of по истории изменений"
+// @Param        Cache-Control  header  string  false  "no-cache - прочитать мимо кеша"
+// @Success      200  {object} entity.SubscriptionsResponse
+// @Failure      400
+// @Failure      500
+// @Router       /users/{user_id}/subscriptions [get]
+func (c *controller) ListUserSubscriptions(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "user_id")
+	userID, err := uuid.Parse(idStr)
+	if err != nil {
+		log.Printf("Invalid user_id format: %s. Error: %s\n", idStr, err)
+		http.Error(w, "Invalid user_id format", http.StatusBadRequest)
+		return
+	}
+
+	req, err := parseListRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.UserID = userID
+
+	resp, err := c.readService(r).GetList(req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("List error: %s", err)
+		http.Error(w, "Getting list error.", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, resp)
+}
+
+// CreateUserSubscription создаёт подписку пользователя
+//
+// @Summary      Создание подписки пользователя
+// @Description  Создание подписки пользователя из пути. user_id в теле можно не передавать. Если подписка выводит расходы пользователя за месячный бюджет, отправляется событие budget.exceeded, а при BUDGET_ENFORCE=true без allow_over_budget возвращается 409
+// @Tags       	 user
+// @Accept       json
+// @Produce		 json,application/msgpack
+// @Param        user_id  path  string  true  "User ID"
+// @Param        request  body  entity.CreateRequest  true  "DataForCreate"
+// @Success      201  {object} entity.Subscription
+// @Failure      400
+// @Failure      409
+// @Failure      500
+// @Router       /users/{user_id}/subscriptions [post]
+func (c *controller) CreateUserSubscription(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "user_id")
+	userID, err := uuid.Parse(idStr)
+	if err != nil {
+		log.Printf("Invalid user_id format: %s. Error: %s\n", idStr, err)
+		http.Error(w, "Invalid user_id format", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Println("read request body error:", err)
+		http.Error(w, "can't read body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	var req entity.CreateRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		log.Println("unmarshalling error:", err)
+		http.Error(w, "can't unmarshal body", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.service.CreateForUser(userID, &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) || errors.Is(err, repository.ErrIDNotFound) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, service.ErrBudgetExceeded) || errors.Is(err, repository.ErrConflict) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		log.Println("create subscription error:", err)
+		http.Error(w, "can't create subscription", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusCreated, resp)
+}
+
+// GetUserSummary возвращает сводку расходов пользователя
+//
+// @Summary      Сводка по подпискам пользователя
+// @Description  Количество действующих подписок, расходы за текущий месяц, прогноз расходов на 12 месяцев и сервисы с наибольшими расходами
+// @Tags       	 user
+// @Produce      json,application/msgpack
+// @Param        user_id  path  string  true  "User ID"
+// @Success      200  {object} entity.UserSummary
+// @Failure      400
+// @Failure      500
+// @Router       /users/{user_id}/summary [get]
+func (c *controller) GetUserSummary(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "user_id")
+	userID, err := uuid.Parse(idStr)
+	if err != nil {
+		log.Printf("Invalid user_id format: %s. Error: %s\n", idStr, err)
+		http.Error(w, "Invalid user_id format", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.readService(r).GetUserSummary(userID)
+	if err != nil {
+		log.Println("getting user summary error:", err)
+		http.Error(w, "can't get user summary", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, resp)
+}
@@ -0,0 +1,193 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"subscribe_service/internal/entity"
+	repository "subscribe_service/internal/repository/postgres"
+	"subscribe_service/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// CreateWebhook регистрирует получателя вебхуков
+//
+// @Summary      Регистрация вебхука
+// @Description  Регистрация URL для получения событий подписок. Пустой список events означает все события. Если secret не передан, он генерируется и возвращается только в этом ответе. Тело запроса подписывается HMAC-SHA256 в заголовке X-Webhook-Signature
+// @Tags       	 webhook
+// @Accept       json
+// @Produce		 json,application/msgpack
+// @Param        request  body  entity.CreateWebhookRequest  true  "Webhook"
+// @Success      201  {object} entity.WebhookEndpoint
+// @Failure      400
+// @Failure      500
+// @Router       /webhooks [post]
+func (c *controller) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Println("read request body error:", err)
+		http.Error(w, "can't read body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	var req entity.CreateWebhookRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		log.Println("unmarshalling error:", err)
+		http.Error(w, "can't unmarshal body", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.service.CreateWebhook(&req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Println("create webhook error:", err)
+		http.Error(w, "can't create webhook", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusCreated, resp)
+}
+
+// ListWebhooks возвращает зарегистрированные вебхуки
+//
+// @Summary      Список вебхуков
+// @Description  Список зарегистрированных вебхуков без секретов
+// @Tags       	 webhook
+// @Produce      json,application/msgpack,text/csv
+// @Success      200  {object} entity.WebhookEndpointsResponse
+// @Failure      500
+// @Router       /webhooks [get]
+func (c *controller) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.readService(r).ListWebhooks()
+	if err != nil {
+		log.Printf("List webhooks error: %s", err)
+		http.Error(w, "Getting webhooks error.", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, resp)
+}
+
+// DeleteWebhook удаляет вебхук вместе с его очередью доставки
+//
+// @Summary      Удаление вебхука
+// @Description  Удаление вебхука по ID
+// @Tags       	 webhook
+// @Param        id   path      string  true  "Webhook ID"
+// @Success      204
+// @Failure      400
+// @Failure      500
+// @Router       /webhooks/{id} [delete]
+func (c *controller) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		log.Printf("Invalid ID format: %s. Error: %s\n", idStr, err)
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	err = c.service.DeleteWebhook(id)
+	if err != nil {
+		if errors.Is(err, repository.ErrIDNotFound) {
+			http.Error(w, fmt.Sprintf("Failed to delete webhook. %s: %s", err, id), http.StatusBadRequest)
+			return
+		}
+		log.Printf("failed to delete webhook: %s\n", err)
+		http.Error(w, "Failed to delete webhook", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListWebhookDeliveries возвращает доставки вебхуков
+//
+// @Summary      Список доставок вебхуков
+// @Description  Страница доставок, начиная с последних, по умолчанию только недоставленных (dead). total - число доставок со статусом
+// @Tags       	 webhook
+// @Produce      json,application/msgpack,text/csv
+// @Param        status  query  string  false  "pending, delivered или dead. По умолчанию dead"
+// @Param        limit   query  int     false  "Размер страницы, по умолчанию 100, не больше 1000"
+// @Param        offset  query  int     false  "Смещение"
+// @Success      200  {object} entity.WebhookDeliveriesResponse
+// @Failure      400
+// @Failure      500
+// @Router       /webhooks/deliveries [get]
+func (c *controller) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	req := &entity.WebhookDeliveriesRequest{Status: query.Get("status")}
+	if req.Status == "" {
+		req.Status = entity.DeliveryDead
+	}
+
+	var err error
+	if s := query.Get("limit"); s != "" {
+		if req.Limit, err = strconv.Atoi(s); err != nil {
+			http.Error(w, "Invalid limit format", http.StatusBadRequest)
+			return
+		}
+	}
+	if s := query.Get("offset"); s != "" {
+		if req.Offset, err = strconv.Atoi(s); err != nil {
+			http.Error(w, "Invalid offset format", http.StatusBadRequest)
+			return
+		}
+	}
+
+	resp, err := c.readService(r).ListWebhookDeliveries(req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("List webhook deliveries error: %s", err)
+		http.Error(w, "Getting webhook deliveries error.", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, resp)
+}
+
+// RedeliverWebhook ставит недоставленное событие в очередь повторно
+//
+// @Summary      Повторная доставка вебхука
+// @Description  Возвращает доставку в статусе dead в очередь со сброшенным счётчиком попыток
+// @Tags       	 webhook
+// @Param        id   path      string  true  "Delivery ID"
+// @Success      202
+// @Failure      400
+// @Failure      500
+// @Router       /webhooks/deliveries/{id}/redeliver [post]
+func (c *controller) RedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		log.Printf("Invalid ID format: %s. Error: %s\n", idStr, err)
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	err = c.service.RedeliverWebhook(id)
+	if err != nil {
+		if errors.Is(err, repository.ErrIDNotFound) {
+			http.Error(w, fmt.Sprintf("No dead delivery found: %s", id), http.StatusBadRequest)
+			return
+		}
+		log.Printf("failed to redeliver webhook: %s\n", err)
+		http.Error(w, "Failed to redeliver webhook", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -15,56 +15,196 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/subscription": {
+        "/analytics/churn": {
             "get": {
-                "description": "Получение списка всех подписок",
+                "description": "Для каждого месяца периода: подписки, действовавшие на его начало, начавшиеся и закончившиеся в нём, и доля закончившихся среди действовавших на начало. Период по умолчанию - последние 12 месяцев, не длиннее 120 месяцев",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/msgpack"
                 ],
                 "tags": [
-                    "subscription"
+                    "analytics"
+                ],
+                "summary": "Отток",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Название сервиса",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Тег",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Категория сервиса из каталога",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Первый месяц (MM-YYYY)",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Последний месяц (MM-YYYY)",
+                        "name": "finish_date",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Получение списка подписок",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/entity.SubscriptionsResponse"
+                            "$ref": "#/definitions/entity.ChurnRateResponse"
                         }
                     },
+                    "400": {
+                        "description": "Bad Request"
+                    },
                     "500": {
                         "description": "Internal Server Error"
                     }
                 }
-            },
-            "put": {
-                "description": "Обновление данных подписки",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/analytics/cohorts": {
+            "get": {
+                "description": "Матрица удержания подписок по месяцу начала: для каждой когорты из периода количество и доля подписок, действующих в каждом следующем месяце по finish_date. Период по умолчанию - последние 12 месяцев, не длиннее 120 месяцев",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/msgpack"
                 ],
                 "tags": [
-                    "subscription"
+                    "analytics"
                 ],
-                "summary": "Обновление данных подписки",
+                "summary": "Удержание когорт",
                 "parameters": [
                     {
-                        "description": "Data for update",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Название сервиса",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Тег",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Категория сервиса из каталога",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Первая когорта (MM-YYYY)",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Последняя когорта и последний месяц матрицы (MM-YYYY)",
+                        "name": "finish_date",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/entity.Subscription"
+                            "$ref": "#/definitions/entity.CohortResponse"
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/analytics/mrr": {
+            "get": {
+                "description": "Ежемесячная регулярная выручка за каждый месяц периода и её изменение к предыдущему месяцу. Считается по тем же помесячным начислениям, что и итоги: пробные месяцы и паузы не входят. С by_service=true ряд строится по каждому сервису. Период по умолчанию - последние 12 месяцев, не длиннее 120 месяцев",
+                "produces": [
+                    "application/json",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "MRR",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Название сервиса",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Тег",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Категория сервиса из каталога",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Первый месяц (MM-YYYY)",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Последний месяц (MM-YYYY)",
+                        "name": "finish_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Ряд по каждому сервису",
+                        "name": "by_service",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/entity.Subscription"
+                            "$ref": "#/definitions/entity.MRRResponse"
                         }
                     },
                     "400": {
@@ -74,27 +214,85 @@ const docTemplate = `{
                         "description": "Internal Server Error"
                     }
                 }
+            }
+        },
+        "/cache/stats": {
+            "get": {
+                "description": "Попадания, промахи, вытеснения и обходы кеша GetRecordByID, списка подписок и итогов с момента запуска. Если кеш отключён, возвращается 404",
+                "produces": [
+                    "application/json",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "cache"
+                ],
+                "summary": "Статистика кеша",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.CacheStats"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    }
+                }
+            }
+        },
+        "/services": {
+            "get": {
+                "description": "Список сервисов каталога, отсортированный по названию",
+                "produces": [
+                    "application/json",
+                    "application/msgpack",
+                    "text/csv"
+                ],
+                "tags": [
+                    "service"
+                ],
+                "summary": "Каталог сервисов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Категория",
+                        "name": "category",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.CatalogServicesResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
             },
             "post": {
-                "description": "Создание новой подписки",
+                "description": "Добавление сервиса с каноническим названием и псевдонимами. Название и псевдонимы не должны совпадать с названиями и псевдонимами других сервисов без учёта регистра",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/msgpack"
                 ],
                 "tags": [
-                    "subscription"
+                    "service"
                 ],
-                "summary": "Создание новой подписки",
+                "summary": "Добавление сервиса в каталог",
                 "parameters": [
                     {
-                        "description": "DataForCreate",
+                        "description": "Service",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/entity.CreateRequest"
+                            "$ref": "#/definitions/entity.CreateCatalogServiceRequest"
                         }
                     }
                 ],
@@ -102,47 +300,46 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/entity.Subscription"
+                            "$ref": "#/definitions/entity.CatalogService"
                         }
                     },
                     "400": {
                         "description": "Bad Request"
                     },
+                    "409": {
+                        "description": "Conflict"
+                    },
                     "500": {
                         "description": "Internal Server Error"
                     }
                 }
             }
         },
-        "/subscription/total": {
+        "/services/{id}": {
             "get": {
-                "description": "Получение общей стоимости и количества подписок с учётом фильтров.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Получение сервиса каталога по ID",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/msgpack"
                 ],
                 "tags": [
-                    "total"
+                    "service"
                 ],
-                "summary": "Получение общей стоимости и количества подписок",
+                "summary": "Получение сервиса каталога",
                 "parameters": [
                     {
-                        "description": "Фильтры для статистики. Все поля опциональны",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/entity.TotalRequest"
-                        }
+                        "type": "string",
+                        "description": "Service ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/entity.TotalResponse"
+                            "$ref": "#/definitions/entity.CatalogService"
                         }
                     },
                     "400": {
@@ -152,52 +349,66 @@ const docTemplate = `{
                         "description": "Internal Server Error"
                     }
                 }
-            }
-        },
-        "/subscription/{id}": {
-            "get": {
-                "description": "Получение данных о подписке",
-                "produces": [
+            },
+            "put": {
+                "description": "Полное обновление сервиса каталога. Новое название переносится во все подписки сервиса",
+                "consumes": [
                     "application/json"
                 ],
+                "produces": [
+                    "application/json",
+                    "application/msgpack"
+                ],
                 "tags": [
-                    "subscription"
+                    "service"
                 ],
-                "summary": "Получение данных о подписке",
+                "summary": "Обновление сервиса каталога",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Subscription ID",
+                        "description": "Service ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Service",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.CreateCatalogServiceRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/entity.Subscription"
+                            "$ref": "#/definitions/entity.CatalogService"
                         }
                     },
                     "400": {
                         "description": "Bad Request"
                     },
+                    "409": {
+                        "description": "Conflict"
+                    },
                     "500": {
                         "description": "Internal Server Error"
                     }
                 }
             },
             "delete": {
-                "description": "Удаление подписки по ID",
+                "description": "Удаление сервиса по ID. Сервис, на который ссылаются подписки, удалить нельзя",
                 "tags": [
-                    "subscription"
+                    "service"
                 ],
-                "summary": "Удаление подписки",
+                "summary": "Удаление сервиса каталога",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Subscription ID",
+                        "description": "Service ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -210,80 +421,2729 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request"
                     },
+                    "409": {
+                        "description": "Conflict"
+                    },
                     "500": {
                         "description": "Internal Server Error"
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "entity.CreateRequest": {
-            "type": "object",
-            "properties": {
-                "finish_date": {
-                    "type": "string"
-                },
-                "price": {
-                    "type": "integer"
-                },
-                "service_name": {
-                    "type": "string"
-                },
-                "start_date": {
-                    "type": "string"
-                },
-                "user_id": {
-                    "type": "string"
-                }
-            }
-        },
-        "entity.Subscription": {
-            "type": "object",
-            "properties": {
-                "finish_date": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string"
-                },
-                "price": {
-                    "type": "integer"
-                },
-                "service_name": {
-                    "type": "string"
-                },
-                "start_date": {
-                    "type": "string"
-                },
-                "user_id": {
-                    "type": "string"
-                }
-            }
-        },
-        "entity.SubscriptionsResponse": {
-            "type": "object",
-            "properties": {
-                "subscriptions": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/entity.Subscription"
-                    }
-                }
-            }
         },
-        "entity.TotalRequest": {
-            "type": "object",
-            "properties": {
-                "finish_date": {
-                    "type": "string"
-                },
-                "service_name": {
-                    "type": "string"
-                },
+        "/subscription": {
+            "get": {
+                "description": "Получение списка подписок с учётом фильтров. Все фильтры опциональны",
+                "produces": [
+                    "application/json",
+                    "application/msgpack",
+                    "text/csv"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Получение списка подписок",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Название сервиса",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подписки, начавшиеся не раньше (MM-YYYY)",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подписки, закончившиеся не позже (MM-YYYY)",
+                        "name": "finish_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Тег",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Категория сервиса из каталога",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "scheduled",
+                            "trialing",
+                            "active",
+                            "paused",
+                            "cancelled",
+                            "expired"
+                        ],
+                        "type": "string",
+                        "description": "Статус на месяц as_of",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подписки, действовавшие в месяце (MM-YYYY). Статус вычисляется на этот месяц, по умолчанию на текущий",
+                        "name": "as_of",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Вернуть значения подписок на конец месяца as_of по истории изменений",
+                        "name": "history",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "no-cache - прочитать мимо кеша",
+                        "name": "Cache-Control",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.SubscriptionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "put": {
+                "description": "Обновление данных подписки. Статус подписки должен меняться допустимым образом. Если подписка выводит расходы пользователя за месячный бюджет, отправляется событие budget.exceeded, а при BUDGET_ENFORCE=true без allow_over_budget возвращается 409",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Обновление данных подписки",
+                "parameters": [
+                    {
+                        "description": "Data for update",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.Subscription"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "post": {
+                "description": "Создание новой подписки. Сервис задаётся service_id или service_name: название ищется в каталоге без учёта регистра и по псевдонимам, неизвестное название добавляется в каталог, если это разрешено настройкой SERVICE_CATALOG_AUTO_CREATE. Если подписка выводит расходы пользователя за месячный бюджет, отправляется событие budget.exceeded, а при BUDGET_ENFORCE=true без allow_over_budget возвращается 409",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Создание новой подписки",
+                "parameters": [
+                    {
+                        "description": "DataForCreate",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.CreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/subscription/batch": {
+            "post": {
+                "description": "Выполняет до 1000 операций (create, update, delete) в одной транзакции. В режиме atomic (по умолчанию) при любой ошибке не применяется ни одна операция и возвращается 422, в режиме best_effort применяются все успешные операции. Создания выполняются первыми, затем обновления и удаления в порядке запроса. Обновления и бюджеты проверяются так же, как в одиночных запросах: превышение бюджета при BUDGET_ENFORCE без allow_over_budget отменяет операцию",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Пакетное создание, обновление и удаление подписок",
+                "parameters": [
+                    {
+                        "description": "Операции",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.BatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.BatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/entity.BatchResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/subscription/churn": {
+            "get": {
+                "description": "Количество подписок, отменённых через cancel, и сумма их ежемесячных платежей по месяцу окончания, причине и сервису. start_date и finish_date ограничивают месяцы окончания",
+                "produces": [
+                    "application/json",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "total"
+                ],
+                "summary": "Отчёт об оттоке",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Первый месяц (MM-YYYY)",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Последний месяц (MM-YYYY)",
+                        "name": "finish_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Service name",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.ChurnResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/subscription/export": {
+            "get": {
+                "description": "Потоковая выгрузка подписок в CSV, JSON Lines или XLSX с теми же фильтрами, что и у списка подписок",
+                "produces": [
+                    "text/csv",
+                    "application/jsonl",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Выгрузка подписок",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv (по умолчанию), jsonl или xlsx",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Название сервиса",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подписки, начавшиеся не раньше (MM-YYYY)",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подписки, закончившиеся не позже (MM-YYYY)",
+                        "name": "finish_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Тег",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Категория сервиса из каталога",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "scheduled",
+                            "trialing",
+                            "active",
+                            "paused",
+                            "cancelled",
+                            "expired"
+                        ],
+                        "type": "string",
+                        "description": "Статус на месяц as_of",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подписки, действовавшие в месяце (MM-YYYY). Статус вычисляется на этот месяц, по умолчанию на текущий",
+                        "name": "as_of",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/subscription/forecast": {
+            "get": {
+                "description": "Ожидаемые списания за каждый месяц начиная с текущего и накопленный итог. Бессрочные подписки продлеваются до конца прогноза, известные даты окончания, пробные периоды, паузы, периоды оплаты и запланированные смены цены учитываются так же, как в итогах: годовая подписка даёт списание только в месяц оплаты",
+                "produces": [
+                    "application/json",
+                    "application/msgpack",
+                    "text/csv"
+                ],
+                "tags": [
+                    "total"
+                ],
+                "summary": "Прогноз расходов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Название сервиса",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Количество месяцев, по умолчанию 12, не больше 120",
+                        "name": "months",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.ForecastResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/subscription/import": {
+            "post": {
+                "description": "Принимает CSV с заголовком user_id, service_name, price, start_date, finish_date, trial_end_date, billing_period (последние три колонки необязательны, даты в форматах MM-YYYY, YYYY-MM-DD или RFC3339, billing_period - monthly или yearly). Файл читается потоково, каждая строка проверяется. С dry_run=true возвращает только ошибки по строкам, иначе загружает корректные строки. Строки, совпадающие с существующими подписками, пропускаются. Если импорт выводит расходы пользователя за бюджет, пишется событие budget.exceeded, а при BUDGET_ENFORCE импорт отменяется с 409",
+                "consumes": [
+                    "text/csv"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Импорт подписок из CSV",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Только проверить файл",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.ImportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/subscription/search": {
+            "get": {
+                "description": "Нечёткий и полнотекстовый поиск подписок по части названия сервиса, в том числе с опечатками и по псевдонимам из каталога. Результаты отсортированы по релевантности, совпавшие слова в highlight обёрнуты в \u003cmark\u003e",
+                "produces": [
+                    "application/json",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Поиск подписок",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Строка поиска",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы, по умолчанию 20, не больше 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.SearchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/subscription/total": {
+            "get": {
+                "description": "Сумма помесячных списаний за период с start_date по finish_date включительно (по умолчанию с начала подписок по текущий месяц) и количество подписок, действовавших в периоде. НЕСОВМЕСТИМОЕ ИЗМЕНЕНИЕ: раньше total был суммой цен подписок, пересекающихся с периодом, без учёта числа месяцев. Теперь подписка начисляется за каждый месяц периода по цене этого месяца с учётом смен цены, месяцы пробного периода и пауз не оплачиваются, годовая подписка начисляется только в месяц оплаты. Итог за несколько месяцев поэтому больше суммы цен подписок (см. README). as_of задаёт период из одного месяца. С group_by=tag или group_by=category дополнительно возвращаются итоги по тегам или категориям",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "total"
+                ],
+                "summary": "Получение общей стоимости и количества подписок",
+                "parameters": [
+                    {
+                        "description": "Фильтры для статистики. Все поля опциональны",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.TotalRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "no-cache - прочитать мимо кеша",
+                        "name": "Cache-Control",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.TotalResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/subscription/trials": {
+            "get": {
+                "description": "Подписки, у которых пробный период заканчивается в окне от текущей даты и которые не отменены до его окончания. После окончания пробного периода отправляется событие subscription.trial_converted",
+                "produces": [
+                    "application/json",
+                    "application/msgpack",
+                    "text/csv"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Заканчивающиеся пробные периоды",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Окно от текущей даты: 7d, 2w или длительность Go (72h). По умолчанию 7d",
+                        "name": "within",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.TrialsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/subscription/upcoming": {
+            "get": {
+                "description": "Подписки, последний день месяца finish_date которых попадает в окно (expiring, event_date - этот день), и бессрочные подписки, у которых в окно попадает следующая дата списания (renewing)",
+                "produces": [
+                    "application/json",
+                    "application/msgpack",
+                    "text/csv"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Ближайшие продления и окончания подписок",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Окно от текущей даты: 30d, 2w или длительность Go (72h). По умолчанию 30d",
+                        "name": "within",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Название сервиса",
+                        "name": "service_name",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.UpcomingResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/subscription/{id}": {
+            "get": {
+                "description": "Получение данных о подписке. Статус вычисляется на текущий месяц или на месяц as_of. С history=true подписка возвращается в том виде, в каком была на конец месяца as_of по истории изменений. Если на тот момент подписки ещё не было или она уже была удалена, возвращается 400",
+                "produces": [
+                    "application/json",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Получение данных о подписке",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Месяц, на который вычисляется статус (MM-YYYY)",
+                        "name": "as_of",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Вернуть значения на конец месяца as_of",
+                        "name": "history",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "no-cache - прочитать мимо кеша",
+                        "name": "Cache-Control",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаление подписки по ID",
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Удаление подписки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/cancel": {
+            "post": {
+                "description": "Отмена подписки с указанием причины. effective=immediately оставляет подписку действующей по текущий день, end_of_month - по последний день текущего месяца, month - по последний день месяца month. День записывается в cancellation.effective_date, а finish_date становится месяц окончания: оплата помесячная, поэтому immediately и end_of_month оплачивают текущий месяц одинаково. Нельзя отменить уже закончившуюся или отменённую подписку, а также перенести окончание на более поздний месяц. Паузы после окончания удаляются. Отправляется событие subscription.cancelled",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Отмена подписки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Отмена",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.CancelRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/pause": {
+            "post": {
+                "description": "Приостанавливает оплату с месяца start_date (по умолчанию текущего) по end_date включительно. Без end_date пауза действует до возобновления. Паузы не должны пересекаться и выходить за даты подписки. Месяцы паузы не входят в итоги",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Приостановка подписки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Пауза",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/entity.PauseRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/price-changes": {
+            "post": {
+                "description": "Планирует новую цену с месяца effective_date, не раньше текущего и не позже окончания подписки. Смена в том же месяце заменяется. Новая цена учитывается в итогах, прогнозе и ближайших платежах начиная с этого месяца",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Смена цены подписки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Смена цены",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.PriceChangeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/price-changes/{month}": {
+            "delete": {
+                "description": "Удаляет смену цены с месяца month (MM-YYYY). Смены прошлых месяцев уже действуют и не отменяются",
+                "produces": [
+                    "application/json",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Отмена смены цены",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Месяц смены, MM-YYYY",
+                        "name": "month",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/resume": {
+            "post": {
+                "description": "Возобновляет оплату с месяца date (по умолчанию текущего). Пауза, действующая в этом месяце, заканчивается предыдущим месяцем",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Возобновление подписки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Месяц возобновления",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/entity.ResumeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/tags": {
+            "get": {
+                "description": "Список тегов с количеством подписок у каждого",
+                "produces": [
+                    "application/json",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "tag"
+                ],
+                "summary": "Список тегов",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.TagsResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "post": {
+                "description": "Создание тега. Теги уникальны без учёта регистра. Теги, переданные в подписке, создаются автоматически",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "tag"
+                ],
+                "summary": "Создание тега",
+                "parameters": [
+                    {
+                        "description": "Tag",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.TagRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Tag"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/tags/{id}": {
+            "put": {
+                "description": "Переименование тега во всех подписках",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "tag"
+                ],
+                "summary": "Переименование тега",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tag ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Tag",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.TagRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Tag"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаление тега по ID. Тег снимается со всех подписок",
+                "tags": [
+                    "tag"
+                ],
+                "summary": "Удаление тега",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tag ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/budget-status": {
+            "get": {
+                "description": "Расходы пользователя за месяц по каждому бюджету, посчитанные так же, как итоги: без пробных месяцев и месяцев паузы",
+                "produces": [
+                    "application/json",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "budget"
+                ],
+                "summary": "Состояние бюджетов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Месяц (MM-YYYY), по умолчанию текущий",
+                        "name": "month",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.BudgetStatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/budgets": {
+            "get": {
+                "description": "Список бюджетов пользователя",
+                "produces": [
+                    "application/json",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "budget"
+                ],
+                "summary": "Бюджеты пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.BudgetsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "post": {
+                "description": "Месячный бюджет пользователя на все подписки, на категорию сервисов или на один сервис из каталога. У пользователя может быть один бюджет на каждую категорию и сервис",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "budget"
+                ],
+                "summary": "Создание бюджета",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Budget",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.BudgetRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Budget"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/budgets/{id}": {
+            "put": {
+                "description": "Замена категории, сервиса и суммы бюджета",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "budget"
+                ],
+                "summary": "Обновление бюджета",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Budget ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Budget",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.BudgetRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Budget"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаление бюджета по ID",
+                "tags": [
+                    "budget"
+                ],
+                "summary": "Удаление бюджета",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Budget ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/subscriptions": {
+            "get": {
+                "description": "Список подписок пользователя. Принимает те же фильтры, что и список подписок, кроме user_id",
+                "produces": [
+                    "application/json",
+                    "application/msgpack",
+                    "text/csv"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Подписки пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Название сервиса",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подписки, начавшиеся не раньше (MM-YYYY)",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подписки, закончившиеся не позже (MM-YYYY)",
+                        "name": "finish_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Тег",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Категория сервиса из каталога",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "scheduled",
+                            "trialing",
+                            "active",
+                            "paused",
+                            "cancelled",
+                            "expired"
+                        ],
+                        "type": "string",
+                        "description": "Статус на месяц as_of",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подписки, действовавшие в месяце (MM-YYYY). Статус вычисляется на этот месяц, по умолчанию на текущий",
+                        "name": "as_of",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Вернуть значения подписок на конец месяца as_of по истории изменений",
+                        "name": "history",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "no-cache - прочитать мимо кеша",
+                        "name": "Cache-Control",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.SubscriptionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "post": {
+                "description": "Создание подписки пользователя из пути. user_id в теле можно не передавать. Если подписка выводит расходы пользователя за месячный бюджет, отправляется событие budget.exceeded, а при BUDGET_ENFORCE=true без allow_over_budget возвращается 409",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Создание подписки пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "DataForCreate",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.CreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/subscriptions.ics": {
+            "get": {
+                "description": "VCALENDAR (RFC 5545) с событием на каждое ближайшее продление и окончание подписок пользователя. Доступ по токену, выданному командой calendartoken",
+                "produces": [
+                    "text/calendar"
+                ],
+                "tags": [
+                    "calendar"
+                ],
+                "summary": "Календарь продлений и окончаний подписок",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Токен доступа",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/summary": {
+            "get": {
+                "description": "Количество действующих подписок, расходы за текущий месяц, прогноз расходов на 12 месяцев и сервисы с наибольшими расходами",
+                "produces": [
+                    "application/json",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Сводка по подпискам пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.UserSummary"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "description": "Список зарегистрированных вебхуков без секретов",
+                "produces": [
+                    "application/json",
+                    "application/msgpack",
+                    "text/csv"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Список вебхуков",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.WebhookEndpointsResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "post": {
+                "description": "Регистрация URL для получения событий подписок. Пустой список events означает все события. Если secret не передан, он генерируется и возвращается только в этом ответе. Тело запроса подписывается HMAC-SHA256 в заголовке X-Webhook-Signature",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Регистрация вебхука",
+                "parameters": [
+                    {
+                        "description": "Webhook",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.CreateWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/entity.WebhookEndpoint"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/webhooks/deliveries": {
+            "get": {
+                "description": "Страница доставок, начиная с последних, по умолчанию только недоставленных (dead). total - число доставок со статусом",
+                "produces": [
+                    "application/json",
+                    "application/msgpack",
+                    "text/csv"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Список доставок вебхуков",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "pending, delivered или dead. По умолчанию dead",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы, по умолчанию 100, не больше 1000",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.WebhookDeliveriesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/webhooks/deliveries/{id}/redeliver": {
+            "post": {
+                "description": "Возвращает доставку в статусе dead в очередь со сброшенным счётчиком попыток",
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Повторная доставка вебхука",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Delivery ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "delete": {
+                "description": "Удаление вебхука по ID",
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Удаление вебхука",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "entity.BatchItemResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "op": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "subscription": {
+                    "$ref": "#/definitions/entity.Subscription"
+                }
+            }
+        },
+        "entity.BatchOperation": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "description": "ID удаляемой подписки",
+                    "type": "string"
+                },
+                "op": {
+                    "type": "string"
+                },
+                "subscription": {
+                    "description": "Subscription - данные для create и update. Для create поле id игнорируется",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/entity.Subscription"
+                        }
+                    ]
+                }
+            }
+        },
+        "entity.BatchRequest": {
+            "type": "object",
+            "properties": {
+                "mode": {
+                    "type": "string"
+                },
+                "operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.BatchOperation"
+                    }
+                }
+            }
+        },
+        "entity.BatchResponse": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "integer"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.BatchItemResult"
+                    }
+                },
+                "succeeded": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.Budget": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "service_id": {
+                    "type": "string"
+                },
+                "service_name": {
+                    "description": "ServiceName - название сервиса из каталога для бюджета на сервис",
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.BudgetRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "category": {
+                    "description": "Category - категория сервисов из каталога. Нельзя указывать вместе с сервисом",
+                    "type": "string"
+                },
+                "service_id": {
+                    "description": "ServiceID - сервис из каталога. Вместо него можно передать service_name",
+                    "type": "string"
+                },
+                "service_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.BudgetStatus": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "exceeded": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "remaining": {
+                    "description": "Remaining - остаток бюджета, отрицательный при превышении",
+                    "type": "integer"
+                },
+                "service_id": {
+                    "type": "string"
+                },
+                "service_name": {
+                    "description": "ServiceName - название сервиса из каталога для бюджета на сервис",
+                    "type": "string"
+                },
+                "spent": {
+                    "description": "Spent - сумма списаний подписок бюджета за месяц",
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.BudgetStatusResponse": {
+            "type": "object",
+            "properties": {
+                "budgets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.BudgetStatus"
+                    }
+                },
+                "month": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.BudgetsResponse": {
+            "type": "object",
+            "properties": {
+                "budgets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Budget"
+                    }
+                }
+            }
+        },
+        "entity.CacheStats": {
+            "type": "object",
+            "properties": {
+                "bypassed": {
+                    "description": "Bypassed - запросы с Cache-Control: no-cache, прочитанные мимо кеша",
+                    "type": "integer"
+                },
+                "evictions": {
+                    "type": "integer"
+                },
+                "hits": {
+                    "type": "integer"
+                },
+                "misses": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.CancelRequest": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "description": "Comment - комментарий пользователя, до 1000 символов",
+                    "type": "string"
+                },
+                "effective": {
+                    "description": "Effective - immediately, end_of_month или month. По умолчанию immediately",
+                    "type": "string",
+                    "enum": [
+                        "immediately",
+                        "end_of_month",
+                        "month"
+                    ]
+                },
+                "month": {
+                    "description": "Month - последний месяц подписки, обязателен для effective=month",
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string",
+                    "enum": [
+                        "too_expensive",
+                        "not_using",
+                        "switched_service",
+                        "technical_issues",
+                        "other"
+                    ]
+                }
+            }
+        },
+        "entity.Cancellation": {
+            "type": "object",
+            "properties": {
+                "cancelled_at": {
+                    "type": "string"
+                },
+                "comment": {
+                    "type": "string"
+                },
+                "effective": {
+                    "type": "string"
+                },
+                "effective_date": {
+                    "description": "EffectiveDate - последний день, по который подписка действует",
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.CatalogService": {
+            "type": "object",
+            "properties": {
+                "aliases": {
+                    "description": "Aliases - другие написания названия, например на другом языке",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "category": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "default_price": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "website": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.CatalogServicesResponse": {
+            "type": "object",
+            "properties": {
+                "services": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.CatalogService"
+                    }
+                }
+            }
+        },
+        "entity.ChurnItem": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "lost_revenue": {
+                    "description": "LostRevenue - сумма ежемесячных платежей отменённых подписок",
+                    "type": "integer"
+                },
+                "month": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "service_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.ChurnRatePoint": {
+            "type": "object",
+            "properties": {
+                "active_at_start": {
+                    "type": "integer"
+                },
+                "churned": {
+                    "type": "integer"
+                },
+                "month": {
+                    "type": "string"
+                },
+                "rate": {
+                    "type": "number"
+                },
+                "started": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.ChurnRateResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.ChurnRatePoint"
+                    }
+                }
+            }
+        },
+        "entity.ChurnResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.ChurnItem"
+                    }
+                }
+            }
+        },
+        "entity.CohortResponse": {
+            "type": "object",
+            "properties": {
+                "cohorts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.CohortRow"
+                    }
+                }
+            }
+        },
+        "entity.CohortRow": {
+            "type": "object",
+            "properties": {
+                "cohort": {
+                    "type": "string"
+                },
+                "rates": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "retained": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.CreateCatalogServiceRequest": {
+            "type": "object",
+            "properties": {
+                "aliases": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "category": {
+                    "type": "string"
+                },
+                "currency": {
+                    "description": "Currency - код валюты ISO 4217, по умолчанию RUB",
+                    "type": "string"
+                },
+                "default_price": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "website": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.CreateRequest": {
+            "type": "object",
+            "properties": {
+                "allow_over_budget": {
+                    "description": "AllowOverBudget - создать подписку, даже если она выводит расходы за бюджет",
+                    "type": "boolean"
+                },
+                "billing_period": {
+                    "description": "BillingPeriod - период оплаты, price - цена за период до первой смены цены.\nПо умолчанию monthly",
+                    "type": "string",
+                    "enum": [
+                        "monthly",
+                        "yearly"
+                    ]
+                },
+                "finish_date": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "integer"
+                },
+                "service_id": {
+                    "description": "ServiceID - запись каталога сервисов. Если не передан, сервис ищется по service_name",
+                    "type": "string"
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "start_date": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "trial_end_date": {
+                    "description": "TrialEndDate - первый платный месяц. До него подписка действует бесплатно",
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.CreateWebhookRequest": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.ForecastMonth": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "description": "Count - количество подписок, по которым ожидаются списания",
+                    "type": "integer"
+                },
+                "cumulative": {
+                    "description": "Cumulative - сумма списаний с первого месяца прогноза по текущий включительно",
+                    "type": "integer"
+                },
+                "month": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.ForecastResponse": {
+            "type": "object",
+            "properties": {
+                "months": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.ForecastMonth"
+                    }
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.ImportError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "line": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.ImportResponse": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "description": "Created - количество созданных подписок",
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.ImportError"
+                    }
+                },
+                "errors_truncated": {
+                    "description": "ErrorsTruncated означает, что в errors попали не все ошибки",
+                    "type": "boolean"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "skipped": {
+                    "description": "Skipped - количество строк, повторяющих существующую подписку или строку выше в файле",
+                    "type": "integer"
+                },
+                "total": {
+                    "description": "Total - количество строк с данными без учёта заголовка",
+                    "type": "integer"
+                },
+                "valid": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.MRRPoint": {
+            "type": "object",
+            "properties": {
+                "change": {
+                    "description": "Change - изменение MRR относительно предыдущего месяца, для первого месяца ряда не задано",
+                    "type": "integer"
+                },
+                "month": {
+                    "type": "string"
+                },
+                "mrr": {
+                    "type": "integer"
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "subscriptions": {
+                    "description": "Subscriptions - количество платных подписок в месяце",
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.MRRResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.MRRPoint"
+                    }
+                }
+            }
+        },
+        "entity.Pause": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "end_date": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "start_date": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.PauseRequest": {
+            "type": "object",
+            "properties": {
+                "end_date": {
+                    "description": "EndDate - последний месяц паузы. Если не задан, пауза действует до возобновления",
+                    "type": "string"
+                },
+                "start_date": {
+                    "description": "StartDate - первый месяц паузы, по умолчанию текущий",
+                    "type": "string"
+                }
+            }
+        },
+        "entity.PriceChange": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "effective_date": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.PriceChangeRequest": {
+            "type": "object",
+            "properties": {
+                "effective_date": {
+                    "description": "EffectiveDate - первый месяц новой цены, не раньше текущего",
+                    "type": "string"
+                },
+                "price": {
+                    "description": "Price - цена за период оплаты подписки",
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.ResumeRequest": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "description": "Date - месяц, с которого оплата возобновляется, по умолчанию текущий",
+                    "type": "string"
+                }
+            }
+        },
+        "entity.SearchResponse": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.SearchResult"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.SearchResult": {
+            "type": "object",
+            "properties": {
+                "allow_over_budget": {
+                    "description": "AllowOverBudget - сохранить изменение, даже если оно выводит расходы за бюджет",
+                    "type": "boolean"
+                },
+                "billing_period": {
+                    "description": "BillingPeriod - период оплаты, price - цена за период до первой смены цены.\nПо умолчанию monthly",
+                    "type": "string",
+                    "enum": [
+                        "monthly",
+                        "yearly"
+                    ]
+                },
+                "cancellation": {
+                    "description": "Cancellation - сведения об отмене через cancel, null для неотменённых подписок",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/entity.Cancellation"
+                        }
+                    ]
+                },
+                "finish_date": {
+                    "type": "string"
+                },
+                "highlight": {
+                    "description": "Highlight - service_name, экранированный для HTML, в котором совпавшие\nслова обёрнуты в \u003cmark\u003e\u003c/mark\u003e",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "pauses": {
+                    "description": "Pauses - история приостановок, меняется только через pause и resume",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Pause"
+                    }
+                },
+                "price": {
+                    "type": "integer"
+                },
+                "price_changes": {
+                    "description": "PriceChanges - смены цены по месяцам. price действует до первой из них.\nМеняются только через price-changes",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.PriceChange"
+                    }
+                },
+                "rank": {
+                    "description": "Rank - релевантность от 0 до 1, лучшие совпадения идут первыми",
+                    "type": "number"
+                },
+                "service_id": {
+                    "type": "string"
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "start_date": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Status - статус на текущий месяц или на месяц as_of. При обновлении игнорируется",
+                    "type": "string",
+                    "enum": [
+                        "scheduled",
+                        "trialing",
+                        "active",
+                        "paused",
+                        "cancelled",
+                        "expired"
+                    ]
+                },
+                "tags": {
+                    "description": "Tags - теги подписки. При обновлении отсутствующее поле оставляет теги без изменений,\nпустой список удаляет все теги",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "trial_end_date": {
+                    "description": "TrialEndDate - первый платный месяц. До него подписка действует бесплатно",
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.ServiceSpend": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "monthly_spend": {
+                    "type": "integer"
+                },
+                "service_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.Subscription": {
+            "type": "object",
+            "properties": {
+                "allow_over_budget": {
+                    "description": "AllowOverBudget - сохранить изменение, даже если оно выводит расходы за бюджет",
+                    "type": "boolean"
+                },
+                "billing_period": {
+                    "description": "BillingPeriod - период оплаты, price - цена за период до первой смены цены.\nПо умолчанию monthly",
+                    "type": "string",
+                    "enum": [
+                        "monthly",
+                        "yearly"
+                    ]
+                },
+                "cancellation": {
+                    "description": "Cancellation - сведения об отмене через cancel, null для неотменённых подписок",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/entity.Cancellation"
+                        }
+                    ]
+                },
+                "finish_date": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "pauses": {
+                    "description": "Pauses - история приостановок, меняется только через pause и resume",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Pause"
+                    }
+                },
+                "price": {
+                    "type": "integer"
+                },
+                "price_changes": {
+                    "description": "PriceChanges - смены цены по месяцам. price действует до первой из них.\nМеняются только через price-changes",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.PriceChange"
+                    }
+                },
+                "service_id": {
+                    "type": "string"
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "start_date": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Status - статус на текущий месяц или на месяц as_of. При обновлении игнорируется",
+                    "type": "string",
+                    "enum": [
+                        "scheduled",
+                        "trialing",
+                        "active",
+                        "paused",
+                        "cancelled",
+                        "expired"
+                    ]
+                },
+                "tags": {
+                    "description": "Tags - теги подписки. При обновлении отсутствующее поле оставляет теги без изменений,\nпустой список удаляет все теги",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "trial_end_date": {
+                    "description": "TrialEndDate - первый платный месяц. До него подписка действует бесплатно",
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.SubscriptionsResponse": {
+            "type": "object",
+            "properties": {
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Subscription"
+                    }
+                }
+            }
+        },
+        "entity.Tag": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "subscription_count": {
+                    "description": "SubscriptionCount - количество подписок с этим тегом",
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.TagRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.TagsResponse": {
+            "type": "object",
+            "properties": {
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Tag"
+                    }
+                }
+            }
+        },
+        "entity.TotalGroup": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.TotalRequest": {
+            "type": "object",
+            "properties": {
+                "as_of": {
+                    "description": "AsOf - итог за один месяц, заменяет start_date и finish_date",
+                    "type": "string"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "finish_date": {
+                    "type": "string"
+                },
+                "group_by": {
+                    "description": "GroupBy - tag или category. Подписка с несколькими тегами входит в итог каждого из них",
+                    "type": "string",
+                    "enum": [
+                        "tag",
+                        "category"
+                    ]
+                },
+                "service_name": {
+                    "type": "string"
+                },
                 "start_date": {
                     "type": "string"
                 },
+                "tag": {
+                    "type": "string"
+                },
                 "user_id": {
                     "type": "string"
                 }
@@ -293,16 +3153,226 @@ const docTemplate = `{
             "type": "object",
             "properties": {
                 "count": {
+                    "description": "Count - количество подписок, действовавших в периоде",
                     "type": "integer"
                 },
+                "group_by": {
+                    "type": "string"
+                },
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.TotalGroup"
+                    }
+                },
                 "service_name": {
                     "type": "string"
                 },
                 "total": {
+                    "description": "Total - сумма помесячных списаний за период, а не сумма цен подписок",
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.TrialItem": {
+            "type": "object",
+            "properties": {
+                "days_left": {
+                    "type": "integer"
+                },
+                "first_charge": {
+                    "description": "FirstCharge - сумма первого платного списания",
+                    "type": "integer"
+                },
+                "subscription": {
+                    "$ref": "#/definitions/entity.Subscription"
+                },
+                "trial_end_date": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.TrialsResponse": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "trials": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.TrialItem"
+                    }
+                }
+            }
+        },
+        "entity.UpcomingItem": {
+            "type": "object",
+            "properties": {
+                "event": {
+                    "type": "string"
+                },
+                "event_date": {
+                    "description": "EventDate - дата списания или последний день месяца окончания подписки",
+                    "type": "string"
+                },
+                "expected_charge": {
+                    "type": "integer"
+                },
+                "subscription": {
+                    "$ref": "#/definitions/entity.Subscription"
+                }
+            }
+        },
+        "entity.UpcomingResponse": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.UpcomingItem"
+                    }
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.UserSummary": {
+            "type": "object",
+            "properties": {
+                "active_count": {
+                    "description": "ActiveCount - количество подписок, действующих в текущем месяце",
+                    "type": "integer"
+                },
+                "month": {
+                    "type": "string"
+                },
+                "monthly_spend": {
                     "type": "integer"
                 },
+                "top_services": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.ServiceSpend"
+                    }
+                },
                 "user_id": {
                     "type": "string"
+                },
+                "yearly_projected_spend": {
+                    "description": "YearlyProjectedSpend - расходы за 12 месяцев начиная с текущего с учётом известных дат окончания",
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.WebhookDeliveriesResponse": {
+            "type": "object",
+            "properties": {
+                "deliveries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.WebhookDelivery"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "delivered_at": {
+                    "type": "string"
+                },
+                "endpoint_id": {
+                    "type": "string"
+                },
+                "event_id": {
+                    "type": "string"
+                },
+                "event_type": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.WebhookEndpoint": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.WebhookEndpointsResponse": {
+            "type": "object",
+            "properties": {
+                "endpoints": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.WebhookEndpoint"
+                    }
                 }
             }
         }
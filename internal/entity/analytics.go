@@ -0,0 +1,59 @@
+package entity
+
+import "github.com/google/uuid"
+
+// AnalyticsRequest - фильтры аналитики, те же, что у итогов.
+// StartDate и FinishDate задают первый и последний месяц ряда
+type AnalyticsRequest struct {
+	UserID      uuid.UUID
+	ServiceName string
+	Tag         string
+	Category    string
+	StartDate   *CustomDate
+	FinishDate  *CustomDate
+	// ByService - MRR отдельно по каждому сервису
+	ByService bool
+}
+
+// MRRPoint - ежемесячная регулярная выручка за месяц Month.
+// Месяцы пробного периода и паузы в MRR не входят
+type MRRPoint struct {
+	Month       CustomDate `json:"month" db:"month"`
+	ServiceName string     `json:"service_name,omitempty" db:"service_name"`
+	MRR         uint       `json:"mrr" db:"mrr"`
+	// Subscriptions - количество платных подписок в месяце
+	Subscriptions uint `json:"subscriptions" db:"subscriptions"`
+	// Change - изменение MRR относительно предыдущего месяца, для первого месяца ряда не задано
+	Change *int `json:"change" db:"change"`
+}
+
+type MRRResponse struct {
+	Items []*MRRPoint `json:"items"`
+}
+
+// ChurnRatePoint - отток за месяц Month: доля подписок, действовавших на начало
+// месяца и закончившихся в нём
+type ChurnRatePoint struct {
+	Month         CustomDate `json:"month" db:"month"`
+	ActiveAtStart uint       `json:"active_at_start" db:"active_at_start"`
+	Started       uint       `json:"started" db:"started"`
+	Churned       uint       `json:"churned" db:"churned"`
+	Rate          float64    `json:"rate" db:"rate"`
+}
+
+type ChurnRateResponse struct {
+	Items []*ChurnRatePoint `json:"items"`
+}
+
+// CohortRow - удержание подписок, начавшихся в месяце Cohort.
+// Retained[i] и Rates[i] относятся к i-му месяцу после начала
+type CohortRow struct {
+	Cohort   CustomDate `json:"cohort"`
+	Size     uint       `json:"size"`
+	Retained []uint     `json:"retained"`
+	Rates    []float64  `json:"rates"`
+}
+
+type CohortResponse struct {
+	Cohorts []*CohortRow `json:"cohorts"`
+}
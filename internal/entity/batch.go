@@ -0,0 +1,53 @@
+package entity
+
+import "github.com/google/uuid"
+
+// Операции пакетного запроса
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// Режимы пакетного запроса
+const (
+	// BatchAtomic применяет все операции или ни одной
+	BatchAtomic = "atomic"
+	// BatchBestEffort применяет все операции, которые удалось выполнить
+	BatchBestEffort = "best_effort"
+)
+
+// Статусы операций пакетного запроса
+const (
+	BatchStatusOK         = "ok"
+	BatchStatusFailed     = "failed"
+	BatchStatusRolledBack = "rolled_back"
+)
+
+type BatchOperation struct {
+	Op string `json:"op"`
+	// ID удаляемой подписки
+	ID uuid.UUID `json:"id,omitempty"`
+	// Subscription - данные для create и update. Для create поле id игнорируется
+	Subscription *Subscription `json:"subscription,omitempty"`
+}
+
+type BatchRequest struct {
+	Mode       string            `json:"mode"`
+	Operations []*BatchOperation `json:"operations"`
+}
+
+type BatchItemResult struct {
+	Index        int           `json:"index"`
+	Op           string        `json:"op"`
+	Status       string        `json:"status"`
+	Subscription *Subscription `json:"subscription,omitempty"`
+	Error        string        `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	Mode      string             `json:"mode"`
+	Succeeded int                `json:"succeeded"`
+	Failed    int                `json:"failed"`
+	Results   []*BatchItemResult `json:"results"`
+}
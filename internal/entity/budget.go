@@ -0,0 +1,61 @@
+package entity
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Budget - месячный лимит расходов пользователя. Без категории и сервиса
+// лимит действует на все подписки, иначе только на подписки категории или сервиса
+type Budget struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	Category  string    `json:"category,omitempty" db:"category"`
+	ServiceID uuid.UUID `json:"service_id,omitempty" db:"service_id"`
+	// ServiceName - название сервиса из каталога для бюджета на сервис
+	ServiceName string    `json:"service_name,omitempty" db:"service_name"`
+	Amount      uint      `json:"amount" db:"amount"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// Matches сообщает, действует ли бюджет на подписку сервиса serviceID из категории category
+func (b *Budget) Matches(serviceID uuid.UUID, category string) bool {
+	if b.ServiceID != uuid.Nil && b.ServiceID != serviceID {
+		return false
+	}
+	if b.Category != "" && !strings.EqualFold(b.Category, category) {
+		return false
+	}
+	return true
+}
+
+type BudgetRequest struct {
+	// Category - категория сервисов из каталога. Нельзя указывать вместе с сервисом
+	Category string `json:"category,omitempty"`
+	// ServiceID - сервис из каталога. Вместо него можно передать service_name
+	ServiceID   uuid.UUID `json:"service_id,omitempty"`
+	ServiceName string    `json:"service_name,omitempty"`
+	Amount      uint      `json:"amount"`
+}
+
+type BudgetsResponse struct {
+	Budgets []*Budget `json:"budgets"`
+}
+
+// BudgetStatus - расходы месяца в рамках бюджета
+type BudgetStatus struct {
+	*Budget
+	// Spent - сумма списаний подписок бюджета за месяц
+	Spent uint `json:"spent" db:"spent"`
+	// Remaining - остаток бюджета, отрицательный при превышении
+	Remaining int  `json:"remaining" db:"-"`
+	Exceeded  bool `json:"exceeded" db:"-"`
+}
+
+type BudgetStatusResponse struct {
+	UserID  uuid.UUID       `json:"user_id"`
+	Month   CustomDate      `json:"month"`
+	Budgets []*BudgetStatus `json:"budgets"`
+}
@@ -0,0 +1,11 @@
+package entity
+
+// CacheStats - счётчики кеша чтения с момента запуска
+type CacheStats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	// Bypassed - запросы с Cache-Control: no-cache, прочитанные мимо кеша
+	Bypassed uint64 `json:"bypassed"`
+	Size     int    `json:"size"`
+}
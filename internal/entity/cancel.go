@@ -0,0 +1,81 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Момент, с которого отменяется подписка. Оплата помесячная, поэтому последним
+// оплаченным месяцем (finish_date) во всех случаях становится месяц окончания,
+// а день, по который подписка действует, хранится в Cancellation.EffectiveDate
+const (
+	// CancelImmediately - подписка действует по текущий день
+	CancelImmediately = "immediately"
+	// CancelEndOfMonth - подписка действует по последний день текущего месяца
+	CancelEndOfMonth = "end_of_month"
+	// CancelAtMonth - подписка действует по последний день месяца из запроса
+	CancelAtMonth = "month"
+)
+
+// Причины отмены подписки
+const (
+	CancelReasonTooExpensive = "too_expensive"
+	CancelReasonNotUsing     = "not_using"
+	CancelReasonSwitched     = "switched_service"
+	CancelReasonTechnical    = "technical_issues"
+	CancelReasonOther        = "other"
+)
+
+// CancelReasons - все допустимые причины отмены
+var CancelReasons = []string{
+	CancelReasonTooExpensive,
+	CancelReasonNotUsing,
+	CancelReasonSwitched,
+	CancelReasonTechnical,
+	CancelReasonOther,
+}
+
+// Cancellation - сведения об отмене подписки. Месяц окончания хранится в finish_date подписки
+type Cancellation struct {
+	Reason    string `json:"reason" db:"reason"`
+	Comment   string `json:"comment,omitempty" db:"comment"`
+	Effective string `json:"effective" db:"effective"`
+	// EffectiveDate - последний день, по который подписка действует
+	EffectiveDate time.Time `json:"effective_date" db:"effective_date"`
+	CancelledAt   time.Time `json:"cancelled_at" db:"cancelled_at"`
+}
+
+type CancelRequest struct {
+	// Effective - immediately, end_of_month или month. По умолчанию immediately
+	Effective string `json:"effective,omitempty" enums:"immediately,end_of_month,month"`
+	// Month - последний месяц подписки, обязателен для effective=month
+	Month  *CustomDate `json:"month,omitempty"`
+	Reason string      `json:"reason" enums:"too_expensive,not_using,switched_service,technical_issues,other"`
+	// Comment - комментарий пользователя, до 1000 символов
+	Comment string `json:"comment,omitempty"`
+}
+
+// ChurnRequest - фильтры отчёта об оттоке. StartDate и FinishDate ограничивают
+// месяцы окончания отменённых подписок
+type ChurnRequest struct {
+	UserID      uuid.UUID
+	ServiceName string
+	StartDate   *CustomDate
+	FinishDate  *CustomDate
+}
+
+// ChurnItem - количество отмен с причиной Reason у сервиса ServiceName,
+// закончившихся в месяце Month
+type ChurnItem struct {
+	Month       CustomDate `json:"month" db:"month"`
+	Reason      string     `json:"reason" db:"reason"`
+	ServiceName string     `json:"service_name" db:"service_name"`
+	Count       uint       `json:"count" db:"count"`
+	// LostRevenue - сумма ежемесячных платежей отменённых подписок
+	LostRevenue uint `json:"lost_revenue" db:"lost_revenue"`
+}
+
+type ChurnResponse struct {
+	Items []*ChurnItem `json:"items"`
+}
@@ -0,0 +1,35 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CatalogService - запись каталога сервисов. Подписки ссылаются на неё по service_id,
+// а service_name подписки всегда совпадает с Name
+type CatalogService struct {
+	ID   uuid.UUID `json:"id" db:"id"`
+	Name string    `json:"name" db:"name"`
+	// Aliases - другие написания названия, например на другом языке
+	Aliases      []string  `json:"aliases" db:"-"`
+	Category     string    `json:"category" db:"category"`
+	DefaultPrice uint      `json:"default_price" db:"default_price"`
+	Currency     string    `json:"currency" db:"currency"`
+	Website      string    `json:"website" db:"website"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+type CreateCatalogServiceRequest struct {
+	Name         string   `json:"name"`
+	Aliases      []string `json:"aliases,omitempty"`
+	Category     string   `json:"category,omitempty"`
+	DefaultPrice uint     `json:"default_price,omitempty"`
+	// Currency - код валюты ISO 4217, по умолчанию RUB
+	Currency string `json:"currency,omitempty"`
+	Website  string `json:"website,omitempty"`
+}
+
+type CatalogServicesResponse struct {
+	Services []*CatalogService `json:"services"`
+}
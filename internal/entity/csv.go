@@ -0,0 +1,137 @@
+package entity
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SubscriptionCSVHeader - колонки подписки в CSV в порядке CSVRecord
+var SubscriptionCSVHeader = []string{"id", "user_id", "service_name", "price", "start_date", "finish_date", "trial_end_date", "billing_period"}
+
+// CSVRecord возвращает поля подписки в порядке SubscriptionCSVHeader
+func (s *Subscription) CSVRecord() []string {
+	return []string{
+		s.ID.String(),
+		s.UserID.String(),
+		s.ServiceName,
+		strconv.FormatUint(uint64(s.Price), 10),
+		formatCSVDate(&s.StartDate),
+		formatCSVDate(s.FinishDate),
+		formatCSVDate(s.TrialEndDate),
+		s.BillingPeriod,
+	}
+}
+
+func (r *SubscriptionsResponse) CSV() ([]string, [][]string) {
+	records := make([][]string, 0, len(r.Subscriptions))
+	for _, sub := range r.Subscriptions {
+		records = append(records, sub.CSVRecord())
+	}
+	return SubscriptionCSVHeader, records
+}
+
+func (r *UpcomingResponse) CSV() ([]string, [][]string) {
+	header := append([]string{"event", "event_date", "expected_charge"}, SubscriptionCSVHeader...)
+	records := make([][]string, 0, len(r.Items))
+	for _, item := range r.Items {
+		record := []string{item.Event, item.EventDate.Format(time.DateOnly), strconv.FormatUint(uint64(item.ExpectedCharge), 10)}
+		records = append(records, append(record, item.Subscription.CSVRecord()...))
+	}
+	return header, records
+}
+
+func (r *TrialsResponse) CSV() ([]string, [][]string) {
+	header := append([]string{"trial_end_date", "days_left", "first_charge"}, SubscriptionCSVHeader...)
+	records := make([][]string, 0, len(r.Trials))
+	for _, trial := range r.Trials {
+		record := []string{trial.TrialEndDate.Format(time.DateOnly), strconv.Itoa(trial.DaysLeft), strconv.FormatUint(uint64(trial.FirstCharge), 10)}
+		records = append(records, append(record, trial.Subscription.CSVRecord()...))
+	}
+	return header, records
+}
+
+func (r *WebhookEndpointsResponse) CSV() ([]string, [][]string) {
+	header := []string{"id", "url", "events", "active", "created_at"}
+	records := make([][]string, 0, len(r.Endpoints))
+	for _, e := range r.Endpoints {
+		records = append(records, []string{
+			e.ID.String(),
+			e.URL,
+			strings.Join(e.Events, ";"),
+			strconv.FormatBool(e.Active),
+			e.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return header, records
+}
+
+func (r *WebhookDeliveriesResponse) CSV() ([]string, [][]string) {
+	header := []string{"id", "endpoint_id", "url", "event_id", "event_type", "status", "attempts",
+		"next_attempt_at", "last_error", "created_at", "delivered_at"}
+	records := make([][]string, 0, len(r.Deliveries))
+	for _, d := range r.Deliveries {
+		var lastError, deliveredAt string
+		if d.LastError != nil {
+			lastError = *d.LastError
+		}
+		if d.DeliveredAt != nil {
+			deliveredAt = d.DeliveredAt.Format(time.RFC3339)
+		}
+
+		records = append(records, []string{
+			d.ID.String(),
+			d.EndpointID.String(),
+			d.URL,
+			d.EventID.String(),
+			d.EventType,
+			d.Status,
+			strconv.Itoa(d.Attempts),
+			d.NextAttemptAt.Format(time.RFC3339),
+			lastError,
+			d.CreatedAt.Format(time.RFC3339),
+			deliveredAt,
+		})
+	}
+	return header, records
+}
+
+func (r *CatalogServicesResponse) CSV() ([]string, [][]string) {
+	header := []string{"id", "name", "aliases", "category", "default_price", "currency", "website", "created_at"}
+	records := make([][]string, 0, len(r.Services))
+	for _, svc := range r.Services {
+		records = append(records, []string{
+			svc.ID.String(),
+			svc.Name,
+			strings.Join(svc.Aliases, ";"),
+			svc.Category,
+			strconv.FormatUint(uint64(svc.DefaultPrice), 10),
+			svc.Currency,
+			svc.Website,
+			svc.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return header, records
+}
+
+// formatCSVDate форматирует дату как в JSON, пустая дата даёт пустую строку
+func formatCSVDate(cd *CustomDate) string {
+	if cd == nil || time.Time(*cd).IsZero() {
+		return ""
+	}
+	return time.Time(*cd).Format(customDateFormat)
+}
+
+func (r *ForecastResponse) CSV() ([]string, [][]string) {
+	header := []string{"month", "total", "count", "cumulative"}
+	records := make([][]string, 0, len(r.Months))
+	for _, m := range r.Months {
+		records = append(records, []string{
+			formatCSVDate(&m.Month),
+			strconv.FormatUint(uint64(m.Total), 10),
+			strconv.FormatUint(uint64(m.Count), 10),
+			strconv.FormatUint(uint64(m.Cumulative), 10),
+		})
+	}
+	return header, records
+}
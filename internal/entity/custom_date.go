@@ -18,6 +18,16 @@ func (cd *CustomDate) UnmarshalJSON(b []byte) error {
 		*cd = CustomDate(time.Time{})
 		return nil
 	}
+	t, err := ParseCustomDate(s)
+	if err != nil {
+		return err
+	}
+	*cd = t
+	return nil
+}
+
+// ParseCustomDate разбирает дату в формате MM-YYYY, RFC3339 или YYYY-MM-DD
+func ParseCustomDate(s string) (CustomDate, error) {
 	t, err := time.Parse(customDateFormat, s)
 	if err != nil {
 		// Fallback to standard time format
@@ -26,12 +36,11 @@ func (cd *CustomDate) UnmarshalJSON(b []byte) error {
 			// Fallback to date-only format
 			t, err = time.Parse("2006-01-02", s)
 			if err != nil {
-				return fmt.Errorf("failed to parse time in any supported format: %s", s)
+				return CustomDate{}, fmt.Errorf("failed to parse time in any supported format: %s", s)
 			}
 		}
 	}
-	*cd = CustomDate(t)
-	return nil
+	return CustomDate(t), nil
 }
 
 func (cd CustomDate) MarshalJSON() ([]byte, error) {
@@ -0,0 +1,29 @@
+package entity
+
+import "github.com/google/uuid"
+
+type ForecastRequest struct {
+	UserID      uuid.UUID
+	ServiceName string
+	// From - первый месяц прогноза
+	From CustomDate
+	// Months - количество месяцев прогноза начиная с From
+	Months int
+}
+
+// ForecastMonth - ожидаемые списания за месяц
+type ForecastMonth struct {
+	Month CustomDate `json:"month" db:"month"`
+	Total uint       `json:"total" db:"total"`
+	// Count - количество подписок, по которым ожидаются списания
+	Count uint `json:"count" db:"count"`
+	// Cumulative - сумма списаний с первого месяца прогноза по текущий включительно
+	Cumulative uint `json:"cumulative" db:"cumulative"`
+}
+
+type ForecastResponse struct {
+	UserID      uuid.UUID        `json:"user_id"`
+	ServiceName string           `json:"service_name"`
+	Months      []*ForecastMonth `json:"months"`
+	Total       uint             `json:"total"`
+}
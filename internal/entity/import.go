@@ -0,0 +1,21 @@
+package entity
+
+type ImportError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+type ImportResponse struct {
+	DryRun bool `json:"dry_run"`
+	// Total - количество строк с данными без учёта заголовка
+	Total int `json:"total"`
+	Valid int `json:"valid"`
+	// Created - количество созданных подписок
+	Created int `json:"created"`
+	// Skipped - количество строк, повторяющих существующую подписку или строку выше в файле
+	Skipped int           `json:"skipped"`
+	Failed  int           `json:"failed"`
+	Errors  []ImportError `json:"errors"`
+	// ErrorsTruncated означает, что в errors попали не все ошибки
+	ErrorsTruncated bool `json:"errors_truncated"`
+}
@@ -0,0 +1,40 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Pause - приостановка оплаты подписки с месяца StartDate по месяц EndDate
+// включительно. Пауза без EndDate действует до возобновления
+type Pause struct {
+	ID        uuid.UUID   `json:"id" db:"id"`
+	StartDate CustomDate  `json:"start_date" db:"start_date"`
+	EndDate   *CustomDate `json:"end_date" db:"end_date"`
+	CreatedAt time.Time   `json:"created_at" db:"created_at"`
+}
+
+// Covers сообщает, приходится ли месяц date на паузу
+func (p *Pause) Covers(date time.Time) bool {
+	month := monthOf(date)
+	if month.Before(monthOf(time.Time(p.StartDate))) {
+		return false
+	}
+	if p.EndDate == nil || time.Time(*p.EndDate).IsZero() {
+		return true
+	}
+	return !month.After(monthOf(time.Time(*p.EndDate)))
+}
+
+type PauseRequest struct {
+	// StartDate - первый месяц паузы, по умолчанию текущий
+	StartDate *CustomDate `json:"start_date,omitempty"`
+	// EndDate - последний месяц паузы. Если не задан, пауза действует до возобновления
+	EndDate *CustomDate `json:"end_date,omitempty"`
+}
+
+type ResumeRequest struct {
+	// Date - месяц, с которого оплата возобновляется, по умолчанию текущий
+	Date *CustomDate `json:"date,omitempty"`
+}
@@ -0,0 +1,18 @@
+package entity
+
+import "time"
+
+// PriceChange - запланированная смена цены подписки. Новая цена действует
+// с месяца EffectiveDate до следующей смены
+type PriceChange struct {
+	EffectiveDate CustomDate `json:"effective_date" db:"effective_date"`
+	Price         uint       `json:"price" db:"price"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+}
+
+type PriceChangeRequest struct {
+	// EffectiveDate - первый месяц новой цены, не раньше текущего
+	EffectiveDate CustomDate `json:"effective_date"`
+	// Price - цена за период оплаты подписки
+	Price uint `json:"price"`
+}
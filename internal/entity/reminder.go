@@ -0,0 +1,10 @@
+package entity
+
+import "time"
+
+type Reminder struct {
+	Subscription *Subscription `json:"subscription"`
+	// FinishDate - последний день месяца окончания подписки
+	FinishDate time.Time `json:"finish_date"`
+	DaysLeft   int       `json:"days_left"`
+}
@@ -0,0 +1,30 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MonthlyCharge - начисление по подписке за месяц. Month - первое число месяца
+type MonthlyCharge struct {
+	SubscriptionID uuid.UUID `json:"subscription_id" db:"subscription_id"`
+	Month          time.Time `json:"month" db:"month"`
+	Charge         uint      `json:"charge" db:"charge"`
+}
+
+// RollupMismatch - расхождение сохранённого начисления подписки за месяц
+// с пересчитанным. Stored или Expected не заданы, если строки нет с одной из сторон
+type RollupMismatch struct {
+	SubscriptionID uuid.UUID  `json:"subscription_id" db:"subscription_id"`
+	Month          CustomDate `json:"month" db:"month"`
+	Stored         *uint      `json:"stored" db:"stored"`
+	Expected       *uint      `json:"expected" db:"expected"`
+}
+
+// RollupCheck - результат сверки помесячных начислений. Through не задан,
+// если начисления ещё не заполнялись
+type RollupCheck struct {
+	Through    *CustomDate       `json:"through"`
+	Mismatches []*RollupMismatch `json:"mismatches"`
+}
@@ -0,0 +1,27 @@
+package entity
+
+import "github.com/google/uuid"
+
+type SearchRequest struct {
+	Query  string
+	UserID uuid.UUID
+	Limit  int
+	Offset int
+}
+
+type SearchResult struct {
+	*Subscription
+	// Rank - релевантность от 0 до 1, лучшие совпадения идут первыми
+	Rank float64 `json:"rank"`
+	// Highlight - service_name, экранированный для HTML, в котором совпавшие
+	// слова обёрнуты в <mark></mark>
+	Highlight string `json:"highlight"`
+}
+
+type SearchResponse struct {
+	Query   string          `json:"query"`
+	Total   int             `json:"total"`
+	Limit   int             `json:"limit"`
+	Offset  int             `json:"offset"`
+	Results []*SearchResult `json:"results"`
+}
@@ -0,0 +1,63 @@
+package entity
+
+import "time"
+
+// Статусы подписки. Статус не хранится, а вычисляется по датам, паузам и отмене
+const (
+	// StatusScheduled - подписка ещё не началась
+	StatusScheduled = "scheduled"
+	// StatusTrialing - идёт пробный период
+	StatusTrialing = "trialing"
+	// StatusActive - подписка оплачивается
+	StatusActive = "active"
+	// StatusPaused - оплата приостановлена
+	StatusPaused = "paused"
+	// StatusCancelled - подписка отменена через cancel и её последний месяц прошёл.
+	// До конца месяца окончания отменённая подписка считается действующей
+	StatusCancelled = "cancelled"
+	// StatusExpired - finish_date прошла без отмены
+	StatusExpired = "expired"
+)
+
+// Statuses - все статусы подписки
+var Statuses = []string{StatusScheduled, StatusTrialing, StatusActive, StatusPaused, StatusCancelled, StatusExpired}
+
+// StatusAt возвращает статус подписки в месяце date. Как и в итогах,
+// месяцы начала, окончания и пробного периода сравниваются целиком
+func (s *Subscription) StatusAt(date time.Time) string {
+	month := monthOf(date)
+
+	if month.Before(monthOf(time.Time(s.StartDate))) {
+		return StatusScheduled
+	}
+	if s.FinishDate != nil && !time.Time(*s.FinishDate).IsZero() && month.After(monthOf(time.Time(*s.FinishDate))) {
+		if s.Cancellation != nil {
+			return StatusCancelled
+		}
+		return StatusExpired
+	}
+	for _, pause := range s.Pauses {
+		if pause.Covers(month) {
+			return StatusPaused
+		}
+	}
+	if s.TrialEndDate != nil && !time.Time(*s.TrialEndDate).IsZero() && month.Before(monthOf(time.Time(*s.TrialEndDate))) {
+		return StatusTrialing
+	}
+
+	return StatusActive
+}
+
+// LastDay возвращает последний день месяца окончания подписки. finish_date хранит
+// только месяц, а подписка действует и оплачивается до его конца.
+// Для бессрочной подписки ok = false
+func (s *Subscription) LastDay() (day time.Time, ok bool) {
+	if s.FinishDate == nil || time.Time(*s.FinishDate).IsZero() {
+		return time.Time{}, false
+	}
+	return monthOf(time.Time(*s.FinishDate)).AddDate(0, 1, -1), true
+}
+
+func monthOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -2,6 +2,13 @@ package entity
 
 import "github.com/google/uuid"
 
+// Периоды оплаты подписки. Годовая подписка оплачивается раз в год
+// в первый платный месяц и в те же месяцы следующих лет
+const (
+	BillingMonthly = "monthly"
+	BillingYearly  = "yearly"
+)
+
 type Subscription struct {
 	ID          uuid.UUID   `json:"id" db:"id"`
 	UserID      uuid.UUID   `json:"user_id" db:"user_id"`
@@ -9,6 +16,26 @@ type Subscription struct {
 	FinishDate  *CustomDate `json:"finish_date" db:"finish_date"`
 	ServiceName string      `json:"service_name" db:"service_name"`
 	Price       uint        `json:"price" db:"price"`
+	ServiceID   uuid.UUID   `json:"service_id" db:"service_id"`
+	// TrialEndDate - первый платный месяц. До него подписка действует бесплатно
+	TrialEndDate *CustomDate `json:"trial_end_date" db:"trial_end_date"`
+	// BillingPeriod - период оплаты, price - цена за период до первой смены цены.
+	// По умолчанию monthly
+	BillingPeriod string `json:"billing_period" db:"billing_period" enums:"monthly,yearly"`
+	// AllowOverBudget - сохранить изменение, даже если оно выводит расходы за бюджет
+	AllowOverBudget bool `json:"allow_over_budget,omitempty" db:"-"`
+	// Pauses - история приостановок, меняется только через pause и resume
+	Pauses []*Pause `json:"pauses" db:"-"`
+	// PriceChanges - смены цены по месяцам. price действует до первой из них.
+	// Меняются только через price-changes
+	PriceChanges []*PriceChange `json:"price_changes" db:"-"`
+	// Cancellation - сведения об отмене через cancel, null для неотменённых подписок
+	Cancellation *Cancellation `json:"cancellation" db:"-"`
+	// Status - статус на текущий месяц или на месяц as_of. При обновлении игнорируется
+	Status string `json:"status" db:"-" enums:"scheduled,trialing,active,paused,cancelled,expired"`
+	// Tags - теги подписки. При обновлении отсутствующее поле оставляет теги без изменений,
+	// пустой список удаляет все теги
+	Tags []string `json:"tags" db:"-"`
 }
 
 type CreateRequest struct {
@@ -17,20 +44,58 @@ type CreateRequest struct {
 	FinishDate  *CustomDate `json:"finish_date,omitempty" db:"finish_date"`
 	ServiceName string      `json:"service_name" db:"service_name"`
 	Price       uint        `json:"price" db:"price"`
+	// ServiceID - запись каталога сервисов. Если не передан, сервис ищется по service_name
+	ServiceID uuid.UUID `json:"service_id,omitempty" db:"service_id"`
+	Tags      []string  `json:"tags,omitempty" db:"-"`
+	// TrialEndDate - первый платный месяц. До него подписка действует бесплатно
+	TrialEndDate *CustomDate `json:"trial_end_date,omitempty" db:"trial_end_date"`
+	// BillingPeriod - период оплаты, price - цена за период до первой смены цены.
+	// По умолчанию monthly
+	BillingPeriod string `json:"billing_period,omitempty" db:"billing_period" enums:"monthly,yearly"`
+	// AllowOverBudget - создать подписку, даже если она выводит расходы за бюджет
+	AllowOverBudget bool `json:"allow_over_budget,omitempty" db:"-"`
 }
 
+// TotalRequest - фильтры итогов. StartDate и FinishDate задают период:
+// в итог входят помесячные списания с StartDate по FinishDate включительно
 type TotalRequest struct {
 	UserID      uuid.UUID   `json:"user_id" db:"user_id"`
 	ServiceName string      `json:"service_name" db:"service_name"`
 	StartDate   *CustomDate `json:"start_date" db:"start_date"`
 	FinishDate  *CustomDate `json:"finish_date,omitempty" db:"finish_date"`
+	Tag         string      `json:"tag,omitempty"`
+	Category    string      `json:"category,omitempty"`
+	// GroupBy - tag или category. Подписка с несколькими тегами входит в итог каждого из них
+	GroupBy string `json:"group_by,omitempty" enums:"tag,category"`
+	// AsOf - итог за один месяц, заменяет start_date и finish_date
+	AsOf *CustomDate `json:"as_of,omitempty"`
+}
+
+type ListRequest struct {
+	UserID      uuid.UUID   `json:"user_id"`
+	ServiceName string      `json:"service_name"`
+	StartDate   *CustomDate `json:"start_date"`
+	FinishDate  *CustomDate `json:"finish_date"`
+	Tag         string      `json:"tag"`
+	Category    string      `json:"category"`
+	// Status оставляет подписки с этим статусом на месяц AsOf
+	Status string `json:"status"`
+	// AsOf оставляет подписки, действовавшие в этом месяце, и задаёт месяц,
+	// на который вычисляется статус. По умолчанию статус вычисляется на текущий месяц
+	AsOf *CustomDate `json:"as_of"`
+	// History - вернуть значения подписок на конец месяца AsOf по истории изменений
+	History bool `json:"history"`
 }
 
 type TotalResponse struct {
 	UserID      uuid.UUID `json:"user_id"`
 	ServiceName string    `json:"service_name"`
-	Total       uint      `json:"total"`
-	Count       uint16    `json:"count"`
+	// Total - сумма помесячных списаний за период, а не сумма цен подписок
+	Total uint `json:"total"`
+	// Count - количество подписок, действовавших в периоде
+	Count   uint16        `json:"count"`
+	GroupBy string        `json:"group_by,omitempty"`
+	Groups  []*TotalGroup `json:"groups,omitempty"`
 }
 
 type SubscriptionsResponse struct {
@@ -0,0 +1,20 @@
+package entity
+
+import "github.com/google/uuid"
+
+type UserSummary struct {
+	UserID uuid.UUID  `json:"user_id"`
+	Month  CustomDate `json:"month"`
+	// ActiveCount - количество подписок, действующих в текущем месяце
+	ActiveCount  int  `json:"active_count"`
+	MonthlySpend uint `json:"monthly_spend"`
+	// YearlyProjectedSpend - расходы за 12 месяцев начиная с текущего с учётом известных дат окончания
+	YearlyProjectedSpend uint            `json:"yearly_projected_spend"`
+	TopServices          []*ServiceSpend `json:"top_services"`
+}
+
+type ServiceSpend struct {
+	ServiceName  string `json:"service_name" db:"service_name"`
+	MonthlySpend uint   `json:"monthly_spend" db:"monthly_spend"`
+	Count        int    `json:"count" db:"count"`
+}
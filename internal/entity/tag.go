@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Группировки итогов по подпискам
+const (
+	GroupByTag      = "tag"
+	GroupByCategory = "category"
+)
+
+type Tag struct {
+	ID   uuid.UUID `json:"id" db:"id"`
+	Name string    `json:"name" db:"name"`
+	// SubscriptionCount - количество подписок с этим тегом
+	SubscriptionCount int       `json:"subscription_count" db:"subscription_count"`
+	CreatedAt         time.Time `json:"created_at" db:"created_at"`
+}
+
+type TagRequest struct {
+	Name string `json:"name"`
+}
+
+type TagsResponse struct {
+	Tags []*Tag `json:"tags"`
+}
+
+// TotalGroup - итог по одному тегу или категории. Подписки без тега
+// или категории попадают в группу с пустым ключом
+type TotalGroup struct {
+	Key   string `json:"key" db:"key"`
+	Total uint   `json:"total" db:"total"`
+	Count int    `json:"count" db:"count"`
+}
@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TrialsRequest struct {
+	UserID uuid.UUID
+	From   time.Time
+	To     time.Time
+}
+
+type TrialItem struct {
+	Subscription *Subscription `json:"subscription"`
+	TrialEndDate time.Time     `json:"trial_end_date"`
+	DaysLeft     int           `json:"days_left"`
+	// FirstCharge - сумма первого платного списания
+	FirstCharge uint `json:"first_charge"`
+}
+
+type TrialsResponse struct {
+	From   time.Time    `json:"from"`
+	To     time.Time    `json:"to"`
+	Trials []*TrialItem `json:"trials"`
+}
@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	UpcomingExpiring = "expiring"
+	UpcomingRenewing = "renewing"
+)
+
+type UpcomingRequest struct {
+	UserID      uuid.UUID
+	ServiceName string
+	From        time.Time
+	To          time.Time
+}
+
+type UpcomingItem struct {
+	Subscription *Subscription `json:"subscription"`
+	Event        string        `json:"event"`
+	// EventDate - дата списания или последний день месяца окончания подписки
+	EventDate      time.Time `json:"event_date"`
+	ExpectedCharge uint      `json:"expected_charge"`
+}
+
+type UpcomingResponse struct {
+	From  time.Time       `json:"from"`
+	To    time.Time       `json:"to"`
+	Items []*UpcomingItem `json:"items"`
+}
+
+type CalendarTokenResponse struct {
+	UserID uuid.UUID `json:"user_id"`
+	Token  string    `json:"token"`
+	URL    string    `json:"url"`
+}
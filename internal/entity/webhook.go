@@ -0,0 +1,101 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Типы событий жизненного цикла подписки
+const (
+	EventSubscriptionCreated   = "subscription.created"
+	EventSubscriptionUpdated   = "subscription.updated"
+	EventSubscriptionCancelled = "subscription.cancelled"
+	EventSubscriptionDeleted   = "subscription.deleted"
+	// EventSubscriptionTrialConverted - пробный период закончился и подписка стала платной
+	EventSubscriptionTrialConverted = "subscription.trial_converted"
+	EventSubscriptionPaused         = "subscription.paused"
+	EventSubscriptionResumed        = "subscription.resumed"
+	// EventBudgetExceeded - создание или обновление подписки вывело расходы пользователя за бюджет
+	EventBudgetExceeded = "budget.exceeded"
+)
+
+// EventTypes перечисляет все известные типы событий
+var EventTypes = []string{
+	EventSubscriptionCreated,
+	EventSubscriptionUpdated,
+	EventSubscriptionCancelled,
+	EventSubscriptionDeleted,
+	EventSubscriptionTrialConverted,
+	EventSubscriptionPaused,
+	EventSubscriptionResumed,
+	EventBudgetExceeded,
+}
+
+// Статусы доставки вебхука
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+type Event struct {
+	ID         uuid.UUID `json:"id"`
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	// Data - подписка события. В budget.exceeded после импорта CSV пустая,
+	// потому что бюджет превышают все импортированные подписки вместе
+	Data *Subscription `json:"data"`
+	// Budget - превышенный бюджет для события budget.exceeded
+	Budget *BudgetStatus `json:"budget,omitempty"`
+}
+
+type WebhookEndpoint struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	URL       string    `json:"url" db:"url"`
+	Secret    string    `json:"secret,omitempty" db:"secret"`
+	Events    []string  `json:"events" db:"-"`
+	Active    bool      `json:"active" db:"active"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret,omitempty"`
+	Events []string `json:"events,omitempty"`
+}
+
+type WebhookEndpointsResponse struct {
+	Endpoints []*WebhookEndpoint `json:"endpoints"`
+}
+
+type WebhookDelivery struct {
+	ID            uuid.UUID       `json:"id" db:"id"`
+	EndpointID    uuid.UUID       `json:"endpoint_id" db:"endpoint_id"`
+	URL           string          `json:"url" db:"url"`
+	Secret        string          `json:"-" db:"secret"`
+	EventID       uuid.UUID       `json:"event_id" db:"event_id"`
+	EventType     string          `json:"event_type" db:"event_type"`
+	Payload       json.RawMessage `json:"payload" swaggertype:"object" db:"payload"`
+	Status        string          `json:"status" db:"status"`
+	Attempts      int             `json:"attempts" db:"attempts"`
+	NextAttemptAt time.Time       `json:"next_attempt_at" db:"next_attempt_at"`
+	LastError     *string         `json:"last_error" db:"last_error"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	DeliveredAt   *time.Time      `json:"delivered_at" db:"delivered_at"`
+}
+
+type WebhookDeliveriesRequest struct {
+	Status string
+	Limit  int
+	Offset int
+}
+
+type WebhookDeliveriesResponse struct {
+	Status     string             `json:"status"`
+	Total      int                `json:"total"`
+	Limit      int                `json:"limit"`
+	Offset     int                `json:"offset"`
+	Deliveries []*WebhookDelivery `json:"deliveries"`
+}
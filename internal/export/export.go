@@ -0,0 +1,92 @@
+// Package export сериализует подписки в файлы выгрузки потоково, по одной записи
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"subscribe_service/internal/entity"
+)
+
+// Форматы выгрузки
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+	FormatXLSX  = "xlsx"
+)
+
+// Writer записывает подписки в выгрузку. Close дописывает окончание
+// файла и должен быть вызван после последней записи
+type Writer interface {
+	Write(sub *entity.Subscription) error
+	Close() error
+}
+
+// NewWriter создаёт Writer для формата
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w)
+	case FormatJSONL:
+		return newJSONLWriter(w), nil
+	case FormatXLSX:
+		return newXLSXWriter(w)
+	}
+
+	return nil, fmt.Errorf("unknown export format: %s", format)
+}
+
+// ContentType возвращает MIME-тип формата
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv;charset=utf-8"
+	case FormatJSONL:
+		return "application/jsonl;charset=utf-8"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "application/octet-stream"
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	cw := &csvWriter{w: csv.NewWriter(w)}
+	if err := cw.w.Write(entity.SubscriptionCSVHeader); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (cw *csvWriter) Write(sub *entity.Subscription) error {
+	return cw.w.Write(sub.CSVRecord())
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+type jsonlWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func newJSONLWriter(w io.Writer) *jsonlWriter {
+	bw := bufio.NewWriter(w)
+	return &jsonlWriter{w: bw, enc: json.NewEncoder(bw)}
+}
+
+// Write пишет подписку одной строкой: json.Encoder завершает каждое значение переводом строки
+func (jw *jsonlWriter) Write(sub *entity.Subscription) error {
+	return jw.enc.Encode(sub)
+}
+
+func (jw *jsonlWriter) Close() error {
+	return jw.w.Flush()
+}
@@ -0,0 +1,88 @@
+// Package ical формирует календари в формате iCalendar (RFC 5545)
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	dateFormat     = "20060102"
+	dateTimeFormat = "20060102T150405Z"
+	// maxLineLength - максимальная длина строки в октетах без CRLF
+	maxLineLength = 75
+)
+
+// Event - событие на весь день
+type Event struct {
+	// UID должен быть стабильным, чтобы клиенты обновляли событие, а не дублировали его
+	UID         string
+	Date        time.Time
+	Summary     string
+	Description string
+}
+
+type Calendar struct {
+	ProdID string
+	Name   string
+	Events []Event
+}
+
+// Marshal возвращает VCALENDAR с событиями. stamp используется как DTSTAMP
+func (c *Calendar) Marshal(stamp time.Time) []byte {
+	var b bytes.Buffer
+	line := func(s string) { writeLine(&b, s) }
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:" + c.ProdID)
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	if c.Name != "" {
+		line("X-WR-CALNAME:" + escape(c.Name))
+	}
+
+	for _, e := range c.Events {
+		line("BEGIN:VEVENT")
+		line("UID:" + e.UID)
+		line("DTSTAMP:" + stamp.UTC().Format(dateTimeFormat))
+		line("DTSTART;VALUE=DATE:" + e.Date.Format(dateFormat))
+		line("DTEND;VALUE=DATE:" + e.Date.AddDate(0, 0, 1).Format(dateFormat))
+		line("SUMMARY:" + escape(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION:" + escape(e.Description))
+		}
+		line("TRANSP:TRANSPARENT")
+		line("END:VEVENT")
+	}
+
+	line("END:VCALENDAR")
+	return b.Bytes()
+}
+
+// writeLine пишет строку с CRLF, перенося её по 75 октетов без разрыва символов UTF-8
+func writeLine(b *bytes.Buffer, s string) {
+	limit := maxLineLength
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		// продолжение начинается с пробела, который входит в длину строки
+		limit = maxLineLength - 1
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// escape экранирует значение типа TEXT
+func escape(s string) string {
+	return escaper.Replace(s)
+}
@@ -0,0 +1,158 @@
+// Package msgpack кодирует значения в MessagePack напрямую по структурам.
+// Имена полей берутся из тегов json, поэтому совпадают с JSON-ответами.
+// Месяцы (entity.CustomDate) и UUID кодируются строками, как в JSON,
+// time.Time - расширением timestamp MessagePack
+package msgpack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"subscribe_service/internal/entity"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const structTag = "json"
+
+func init() {
+	msgpack.Register(entity.CustomDate{}, encodeCustomDate, decodeCustomDate)
+	msgpack.Register(uuid.UUID{}, encodeUUID, decodeUUID)
+	msgpack.Register(json.RawMessage{}, encodeRawJSON, decodeRawJSON)
+}
+
+// Marshal возвращает MessagePack-представление v. Ключи словарей сортируются,
+// чтобы результат был детерминированным
+func Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag(structTag)
+	enc.SetSortMapKeys(true)
+	enc.UseCompactInts(true)
+
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal разбирает MessagePack, полученный от Marshal, в v
+func Unmarshal(data []byte, v any) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag(structTag)
+	return dec.Decode(v)
+}
+
+func encodeCustomDate(e *msgpack.Encoder, v reflect.Value) error {
+	t := time.Time(v.Interface().(entity.CustomDate))
+	if t.IsZero() {
+		return e.EncodeNil()
+	}
+	return e.EncodeString(t.Format("01-2006"))
+}
+
+func decodeCustomDate(d *msgpack.Decoder, v reflect.Value) error {
+	s, err := decodeNullableString(d)
+	if err != nil || s == "" {
+		v.Set(reflect.ValueOf(entity.CustomDate{}))
+		return err
+	}
+
+	date, err := entity.ParseCustomDate(s)
+	if err != nil {
+		return fmt.Errorf("msgpack: %w", err)
+	}
+	v.Set(reflect.ValueOf(date))
+	return nil
+}
+
+func encodeUUID(e *msgpack.Encoder, v reflect.Value) error {
+	return e.EncodeString(v.Interface().(uuid.UUID).String())
+}
+
+func decodeUUID(d *msgpack.Decoder, v reflect.Value) error {
+	s, err := decodeNullableString(d)
+	if err != nil || s == "" {
+		v.Set(reflect.ValueOf(uuid.Nil))
+		return err
+	}
+
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return fmt.Errorf("msgpack: %w", err)
+	}
+	v.Set(reflect.ValueOf(id))
+	return nil
+}
+
+// encodeRawJSON кодирует готовый JSON (например, payload событий) как вложенное
+// значение, а не как строку байт
+func encodeRawJSON(e *msgpack.Encoder, v reflect.Value) error {
+	raw := v.Interface().(json.RawMessage)
+	if len(raw) == 0 {
+		return e.EncodeNil()
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return fmt.Errorf("msgpack: decoding raw json: %w", err)
+	}
+	return e.Encode(jsonNumbers(value))
+}
+
+func decodeRawJSON(d *msgpack.Decoder, v reflect.Value) error {
+	value, err := d.DecodeInterfaceLoose()
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		v.Set(reflect.ValueOf(json.RawMessage(nil)))
+		return nil
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("msgpack: encoding raw json: %w", err)
+	}
+	v.Set(reflect.ValueOf(json.RawMessage(raw)))
+	return nil
+}
+
+// jsonNumbers заменяет json.Number на целые или дробные числа
+func jsonNumbers(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case []any:
+		for i := range v {
+			v[i] = jsonNumbers(v[i])
+		}
+	case map[string]any:
+		for k := range v {
+			v[k] = jsonNumbers(v[k])
+		}
+	}
+	return v
+}
+
+func decodeNullableString(d *msgpack.Decoder) (string, error) {
+	value, err := d.DecodeInterface()
+	if err != nil || value == nil {
+		return "", err
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("msgpack: expected string, got %T", value)
+	}
+	return s, nil
+}
@@ -0,0 +1,156 @@
+package msgpack
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"subscribe_service/internal/entity"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func month(year int, m time.Month) entity.CustomDate {
+	return entity.CustomDate(time.Date(year, m, 1, 0, 0, 0, 0, time.UTC))
+}
+
+func testSubscription() *entity.Subscription {
+	finish := month(2025, time.December)
+	trialEnd := month(2025, time.March)
+	resumed := month(2025, time.June)
+	return &entity.Subscription{
+		ID:           uuid.New(),
+		UserID:       uuid.New(),
+		StartDate:    month(2025, time.January),
+		FinishDate:   &finish,
+		ServiceName:  "Yandex Plus",
+		Price:        400,
+		ServiceID:    uuid.New(),
+		TrialEndDate: &trialEnd,
+		Pauses: []*entity.Pause{
+			{StartDate: month(2025, time.May), EndDate: &resumed},
+		},
+		Status: entity.StatusTrialing,
+		Tags:   []string{"family", "music"},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	sub := testSubscription()
+	sub.FinishDate = nil
+
+	var got entity.Subscription
+	data, err := Marshal(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&got, sub) {
+		t.Fatalf("round trip mismatch:\n got %+v\nwant %+v", got, *sub)
+	}
+}
+
+func TestRoundTripNested(t *testing.T) {
+	resp := &entity.SearchResponse{
+		Query: "yand",
+		Total: 1,
+		Limit: 20,
+		Results: []*entity.SearchResult{
+			{Subscription: testSubscription(), Rank: 0.75, Highlight: "<mark>Yandex</mark> Plus"},
+		},
+	}
+
+	data, err := Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got entity.SearchResponse
+	if err := Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&got, resp) {
+		t.Fatalf("round trip mismatch:\n got %+v\nwant %+v", got, *resp)
+	}
+}
+
+func TestRoundTripRawJSON(t *testing.T) {
+	delivery := &entity.WebhookDelivery{
+		ID:            uuid.New(),
+		EndpointID:    uuid.New(),
+		URL:           "https://example.com/hook",
+		Secret:        "must not leak",
+		EventID:       uuid.New(),
+		EventType:     "subscription.created",
+		Payload:       json.RawMessage(`{"data":{"price":400,"tags":["a"]},"type":"subscription.created"}`),
+		Status:        entity.DeliveryPending,
+		NextAttemptAt: time.Date(2025, 3, 24, 9, 0, 0, 0, time.UTC),
+		CreatedAt:     time.Date(2025, 3, 24, 8, 59, 0, 0, time.UTC),
+	}
+
+	data, err := Marshal(delivery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got entity.WebhookDelivery
+	if err := Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Secret != "" {
+		t.Fatal("field tagged json:\"-\" is encoded")
+	}
+	if !json.Valid(got.Payload) || string(got.Payload) != string(delivery.Payload) {
+		t.Fatalf("payload = %s, want %s", got.Payload, delivery.Payload)
+	}
+	got.Secret, got.Payload = delivery.Secret, delivery.Payload
+	got.NextAttemptAt, got.CreatedAt = got.NextAttemptAt.UTC(), got.CreatedAt.UTC()
+	if !reflect.DeepEqual(&got, delivery) {
+		t.Fatalf("round trip mismatch:\n got %+v\nwant %+v", got, *delivery)
+	}
+}
+
+// TestKeysMatchJSON проверяет, что имена полей и представление месяцев и ID
+// совпадают с JSON-ответом
+func TestKeysMatchJSON(t *testing.T) {
+	sub := testSubscription()
+
+	jsonData, err := json.Marshal(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromJSON map[string]any
+	if err := json.Unmarshal(jsonData, &fromJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := Marshal(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromMsgpack map[string]any
+	if err := msgpack.Unmarshal(data, &fromMsgpack); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := keys(fromMsgpack), keys(fromJSON); !reflect.DeepEqual(got, want) {
+		t.Fatalf("keys = %v, want %v", got, want)
+	}
+	for _, key := range []string{"id", "user_id", "start_date", "finish_date", "service_name", "status"} {
+		if fromMsgpack[key] != fromJSON[key] {
+			t.Errorf("%s = %v, want %v", key, fromMsgpack[key], fromJSON[key])
+		}
+	}
+}
+
+func keys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,78 @@
+package outbox
+
+import (
+	"context"
+	"io"
+	"log"
+	"subscribe_service/internal/config"
+	"subscribe_service/internal/entity"
+	"time"
+)
+
+type Store interface {
+	ProcessOutbox(publisher string, limit int, publish func(event *entity.Event) error) (int, error)
+}
+
+// Dispatcher читает события из outbox и передаёт их публикатору
+type Dispatcher struct {
+	store        Store
+	publisher    Publisher
+	name         string
+	pollInterval time.Duration
+	batchSize    int
+}
+
+func NewDispatcher(cfg *config.Config, store Store, publisher Publisher) *Dispatcher {
+	name := cfg.OutboxPublisher
+	if name == "" {
+		name = "log"
+	}
+
+	return &Dispatcher{
+		store:        store,
+		publisher:    publisher,
+		name:         name,
+		pollInterval: cfg.OutboxPollInterval,
+		batchSize:    cfg.OutboxBatchSize,
+	}
+}
+
+// Run публикует события до отмены контекста. Полный пакет означает,
+// что в outbox могут остаться события, поэтому следующий опрос идёт сразу
+func (d *Dispatcher) Run(ctx context.Context) {
+	log.Println("Start outbox dispatcher...")
+	defer d.close()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Outbox dispatcher stopped")
+			return
+		case <-timer.C:
+		}
+
+		published, err := d.store.ProcessOutbox(d.name, d.batchSize, func(event *entity.Event) error {
+			return d.publisher.Publish(ctx, event)
+		})
+		if err != nil {
+			log.Println("outbox dispatch error:", err)
+		}
+
+		if err == nil && published == d.batchSize {
+			timer.Reset(0)
+		} else {
+			timer.Reset(d.pollInterval)
+		}
+	}
+}
+
+func (d *Dispatcher) close() {
+	if c, ok := d.publisher.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			log.Println("closing outbox publisher error:", err)
+		}
+	}
+}
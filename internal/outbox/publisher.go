@@ -0,0 +1,144 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"subscribe_service/internal/config"
+	"subscribe_service/internal/entity"
+	"sync"
+	"time"
+)
+
+// Publisher доставляет событие потребителям. Доставка выполняется как минимум
+// один раз, поэтому потребители должны быть идемпотентны по ID события
+type Publisher interface {
+	Publish(ctx context.Context, event *entity.Event) error
+}
+
+// NewPublisher создаёт публикатор, выбранный в конфигурации: log, http или file
+func NewPublisher(cfg *config.Config) (Publisher, error) {
+	switch cfg.OutboxPublisher {
+	case "", "log":
+		return &LogPublisher{}, nil
+	case "http":
+		if cfg.OutboxHTTPURL == "" {
+			return nil, fmt.Errorf("OUTBOX_HTTP_URL is required for http publisher")
+		}
+		return NewHTTPPublisher(cfg.OutboxHTTPURL, 10*time.Second), nil
+	case "file":
+		if cfg.OutboxFilePath == "" {
+			return nil, fmt.Errorf("OUTBOX_FILE_PATH is required for file publisher")
+		}
+		return NewFilePublisher(cfg.OutboxFilePath)
+	}
+
+	return nil, fmt.Errorf("unknown outbox publisher: %s", cfg.OutboxPublisher)
+}
+
+// LogPublisher пишет события в лог
+type LogPublisher struct{}
+
+// Publish пишет ID подписки события, а для событий без подписки, например
+// budget.exceeded после импорта CSV, - ID бюджета
+func (p *LogPublisher) Publish(_ context.Context, event *entity.Event) error {
+	switch {
+	case event.Data != nil:
+		log.Printf("event %s %s subscription %s\n", event.ID, event.Type, event.Data.ID)
+	case event.Budget != nil && event.Budget.Budget != nil:
+		log.Printf("event %s %s budget %s\n", event.ID, event.Type, event.Budget.ID)
+	default:
+		log.Printf("event %s %s\n", event.ID, event.Type)
+	}
+	return nil
+}
+
+// HTTPPublisher отправляет события POST-запросом в формате JSON
+type HTTPPublisher struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPPublisher(url string, timeout time.Duration) *HTTPPublisher {
+	return &HTTPPublisher{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *HTTPPublisher) Publish(ctx context.Context, event *entity.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshalling event error: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request error: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json;charset=utf-8")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return nil
+}
+
+// FilePublisher дописывает события в файл в формате JSON Lines
+type FilePublisher struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFilePublisher(path string) (*FilePublisher, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening outbox file error: %w", err)
+	}
+	return &FilePublisher{file: file}, nil
+}
+
+func (p *FilePublisher) Publish(_ context.Context, event *entity.Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshalling event error: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, err := p.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("writing event error: %w", err)
+	}
+	return p.file.Sync()
+}
+
+func (p *FilePublisher) Close() error {
+	return p.file.Close()
+}
+
+// MultiPublisher передаёт событие всем публикаторам по очереди
+// и останавливается на первой ошибке
+type MultiPublisher []Publisher
+
+func (m MultiPublisher) Publish(ctx context.Context, event *entity.Event) error {
+	for _, p := range m {
+		if err := p.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"subscribe_service/internal/entity"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// budgetEvent - событие budget.exceeded после импорта CSV, у которого нет подписки
+func budgetEvent() *entity.Event {
+	return &entity.Event{
+		ID:         uuid.New(),
+		Type:       entity.EventBudgetExceeded,
+		OccurredAt: time.Now().UTC(),
+		Budget: &entity.BudgetStatus{
+			Budget: &entity.Budget{ID: uuid.New(), UserID: uuid.New(), Amount: 100},
+			Spent:  150,
+		},
+	}
+}
+
+func TestLogPublisherBudgetEventWithoutSubscription(t *testing.T) {
+	p := &LogPublisher{}
+	for _, event := range []*entity.Event{budgetEvent(), {ID: uuid.New(), Type: entity.EventBudgetExceeded}} {
+		if err := p.Publish(context.Background(), event); err != nil {
+			t.Fatalf("publish error: %v", err)
+		}
+	}
+}
+
+func TestFilePublisherBudgetEventWithoutSubscription(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	p, err := NewFilePublisher(path)
+	if err != nil {
+		t.Fatalf("new publisher error: %v", err)
+	}
+	defer p.Close()
+
+	event := budgetEvent()
+	if err := p.Publish(context.Background(), event); err != nil {
+		t.Fatalf("publish error: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open error: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() {
+		t.Fatal("no event written")
+	}
+	var got entity.Event
+	if err := json.Unmarshal(scanner.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+	if got.Data != nil {
+		t.Errorf("data = %+v, want nil", got.Data)
+	}
+	if got.Budget == nil || got.Budget.Budget == nil || got.Budget.ID != event.Budget.ID {
+		t.Errorf("budget = %+v, want %s", got.Budget, event.Budget.ID)
+	}
+}
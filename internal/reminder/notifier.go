@@ -0,0 +1,93 @@
+package reminder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/smtp"
+	"subscribe_service/internal/config"
+	"subscribe_service/internal/entity"
+	"time"
+)
+
+// Notifier отправляет напоминание об окончании подписки
+type Notifier interface {
+	Notify(ctx context.Context, reminder *entity.Reminder) error
+}
+
+// NewNotifier создаёт уведомитель, выбранный в конфигурации: log или smtp
+func NewNotifier(cfg *config.Config) (Notifier, error) {
+	switch cfg.ReminderNotifier {
+	case "", "log":
+		return &LogNotifier{}, nil
+	case "smtp":
+		if cfg.SMTPAddr == "" || cfg.SMTPFrom == "" || cfg.SMTPTo == "" {
+			return nil, fmt.Errorf("SMTP_ADDR, SMTP_FROM and SMTP_TO are required for smtp notifier")
+		}
+		return NewSMTPNotifier(cfg.SMTPAddr, cfg.SMTPUser, cfg.SMTPPassword, cfg.SMTPFrom, cfg.SMTPTo), nil
+	}
+
+	return nil, fmt.Errorf("unknown reminder notifier: %s", cfg.ReminderNotifier)
+}
+
+// LogNotifier пишет напоминания в лог
+type LogNotifier struct{}
+
+func (n *LogNotifier) Notify(_ context.Context, reminder *entity.Reminder) error {
+	log.Printf("reminder: subscription %s of user %s to %s ends in %d days\n",
+		reminder.Subscription.ID, reminder.Subscription.UserID, reminder.Subscription.ServiceName, reminder.DaysLeft)
+	return nil
+}
+
+// SMTPNotifier отправляет напоминания письмом через SMTP-сервер
+type SMTPNotifier struct {
+	addr string
+	auth smtp.Auth
+	from string
+	to   string
+}
+
+// NewSMTPNotifier создаёт уведомитель. Если user пуст, сервер используется без авторизации
+func NewSMTPNotifier(addr, user, password, from, to string) *SMTPNotifier {
+	n := &SMTPNotifier{
+		addr: addr,
+		from: from,
+		to:   to,
+	}
+
+	if user != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		n.auth = smtp.PlainAuth("", user, password, host)
+	}
+
+	return n
+}
+
+func (n *SMTPNotifier) Notify(_ context.Context, reminder *entity.Reminder) error {
+	if err := smtp.SendMail(n.addr, n.auth, n.from, []string{n.to}, n.message(reminder)); err != nil {
+		return fmt.Errorf("sending reminder email error: %w", err)
+	}
+	return nil
+}
+
+func (n *SMTPNotifier) message(reminder *entity.Reminder) []byte {
+	sub := reminder.Subscription
+	subject := fmt.Sprintf("Subscription to %s ends in %d days", sub.ServiceName, reminder.DaysLeft)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", n.from)
+	fmt.Fprintf(&b, "To: %s\r\n", n.to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	fmt.Fprintf(&b, "Subscription %s of user %s to %s ends on %s.\r\n",
+		sub.ID, sub.UserID, sub.ServiceName, reminder.FinishDate.Format("2006-01-02"))
+	fmt.Fprintf(&b, "Monthly price: %d.\r\n", sub.Price)
+
+	return b.Bytes()
+}
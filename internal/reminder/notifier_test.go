@@ -0,0 +1,146 @@
+package reminder
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"subscribe_service/internal/entity"
+	"testing"
+	"time"
+)
+
+// smtpMessage - письмо, принятое fakeSMTP
+type smtpMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+// fakeSMTP поднимает минимальный SMTP-сервер без авторизации и TLS
+// и возвращает его адрес и канал принятых писем
+func fakeSMTP(t *testing.T) (string, <-chan smtpMessage) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	messages := make(chan smtpMessage, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+
+		var msg smtpMessage
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.TrimRight(line, "\r\n")
+
+			switch upper := strings.ToUpper(cmd); {
+			case strings.HasPrefix(upper, "EHLO"), strings.HasPrefix(upper, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(upper, "MAIL FROM:"):
+				msg.from = strings.Trim(cmd[len("MAIL FROM:"):], "<>")
+				reply("250 OK")
+			case strings.HasPrefix(upper, "RCPT TO:"):
+				msg.to = append(msg.to, strings.Trim(cmd[len("RCPT TO:"):], "<>"))
+				reply("250 OK")
+			case upper == "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				var data strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				msg.data = data.String()
+				reply("250 OK")
+			case upper == "QUIT":
+				reply("221 Bye")
+				messages <- msg
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	return ln.Addr().String(), messages
+}
+
+func TestSMTPNotifierSendsReminder(t *testing.T) {
+	addr, messages := fakeSMTP(t)
+	n := NewSMTPNotifier(addr, "", "", "billing@example.com", "user@example.com")
+
+	sub := subscriptionEnding(time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC))
+	err := n.Notify(context.Background(), &entity.Reminder{
+		Subscription: sub,
+		FinishDate:   time.Time(*sub.FinishDate),
+		DaysLeft:     7,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var msg smtpMessage
+	select {
+	case msg = <-messages:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+
+	if msg.from != "billing@example.com" || len(msg.to) != 1 || msg.to[0] != "user@example.com" {
+		t.Fatalf("envelope = %s -> %v", msg.from, msg.to)
+	}
+	for _, want := range []string{
+		"Subject: Subscription to Yandex Plus ends in 7 days",
+		"ends on 2025-03-31",
+		"Monthly price: 400.",
+		sub.ID.String(),
+	} {
+		if !strings.Contains(msg.data, want) {
+			t.Errorf("message does not contain %q:\n%s", want, msg.data)
+		}
+	}
+}
+
+func TestSMTPNotifierReportsRejection(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("554 No service\r\n"))
+	}()
+
+	n := NewSMTPNotifier(ln.Addr().String(), "", "", "billing@example.com", "user@example.com")
+	sub := subscriptionEnding(time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC))
+	err = n.Notify(context.Background(), &entity.Reminder{Subscription: sub, FinishDate: time.Time(*sub.FinishDate), DaysLeft: 7})
+	if err == nil {
+		t.Fatal("expected error from rejecting server")
+	}
+}
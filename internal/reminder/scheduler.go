@@ -0,0 +1,84 @@
+package reminder
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"subscribe_service/internal/config"
+	"subscribe_service/internal/entity"
+	"time"
+)
+
+type Store interface {
+	RunReminders(today time.Time, daysBefore int, notify func(reminder *entity.Reminder) error) (sent int, locked bool, err error)
+}
+
+// Scheduler раз в день в заданное время рассылает напоминания
+// о подписках, которые скоро закончатся
+type Scheduler struct {
+	store      Store
+	notifier   Notifier
+	hour       int
+	minute     int
+	daysBefore int
+}
+
+func NewScheduler(cfg *config.Config, store Store, notifier Notifier) (*Scheduler, error) {
+	at, err := time.Parse("15:04", cfg.ReminderTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REMINDER_TIME %q: %w", cfg.ReminderTime, err)
+	}
+
+	return &Scheduler{
+		store:      store,
+		notifier:   notifier,
+		hour:       at.Hour(),
+		minute:     at.Minute(),
+		daysBefore: cfg.ReminderDaysBefore,
+	}, nil
+}
+
+// Run ждёт очередного времени запуска и рассылает напоминания до отмены контекста
+func (s *Scheduler) Run(ctx context.Context) {
+	log.Println("Start reminder scheduler...")
+	for {
+		next := s.nextRun(time.Now())
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			log.Println("Reminder scheduler stopped")
+			return
+		case <-timer.C:
+		}
+
+		s.runOnce(ctx, next)
+	}
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, now time.Time) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	sent, locked, err := s.store.RunReminders(today, s.daysBefore, func(reminder *entity.Reminder) error {
+		return s.notifier.Notify(ctx, reminder)
+	})
+	if !locked && err == nil {
+		log.Println("reminders are being sent by another replica")
+		return
+	}
+	if err != nil {
+		log.Println("sending reminders error:", err)
+	}
+
+	log.Printf("sent %d reminders\n", sent)
+}
+
+// nextRun возвращает ближайшее время запуска после now
+func (s *Scheduler) nextRun(now time.Time) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), s.hour, s.minute, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
@@ -0,0 +1,124 @@
+package reminder
+
+import (
+	"context"
+	"errors"
+	"subscribe_service/internal/entity"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeStore повторяет контракт RunReminders на подписках в памяти
+type fakeStore struct {
+	subs  []*entity.Subscription
+	sent  map[uuid.UUID]time.Time
+	today time.Time
+}
+
+func (s *fakeStore) RunReminders(today time.Time, daysBefore int, notify func(reminder *entity.Reminder) error) (int, bool, error) {
+	s.today = today
+
+	var sent int
+	var errs []error
+	for _, sub := range s.subs {
+		finish := time.Time(*sub.FinishDate)
+		if finish.Before(today) || finish.After(today.AddDate(0, 0, daysBefore)) {
+			continue
+		}
+		if last, ok := s.sent[sub.ID]; ok && !last.Before(today) {
+			continue
+		}
+
+		err := notify(&entity.Reminder{
+			Subscription: sub,
+			FinishDate:   finish,
+			DaysLeft:     int(finish.Sub(today).Hours() / 24),
+		})
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		s.sent[sub.ID] = finish
+		sent++
+	}
+	return sent, true, errors.Join(errs...)
+}
+
+// fakeNotifier запоминает напоминания и отказывает подпискам из fail
+type fakeNotifier struct {
+	reminders []*entity.Reminder
+	fail      map[uuid.UUID]bool
+}
+
+func (n *fakeNotifier) Notify(_ context.Context, reminder *entity.Reminder) error {
+	if n.fail[reminder.Subscription.ID] {
+		return errors.New("notifier is down")
+	}
+	n.reminders = append(n.reminders, reminder)
+	return nil
+}
+
+func subscriptionEnding(date time.Time) *entity.Subscription {
+	finish := entity.CustomDate(date)
+	return &entity.Subscription{
+		ID:          uuid.New(),
+		UserID:      uuid.New(),
+		ServiceName: "Yandex Plus",
+		Price:       400,
+		FinishDate:  &finish,
+	}
+}
+
+func TestRunOnceNotifiesAndRetries(t *testing.T) {
+	today := time.Date(2025, 3, 24, 0, 0, 0, 0, time.UTC)
+	soon := subscriptionEnding(time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC))
+	later := subscriptionEnding(time.Date(2025, 4, 30, 0, 0, 0, 0, time.UTC))
+	flaky := subscriptionEnding(time.Date(2025, 3, 28, 0, 0, 0, 0, time.UTC))
+
+	store := &fakeStore{subs: []*entity.Subscription{soon, later, flaky}, sent: map[uuid.UUID]time.Time{}}
+	notifier := &fakeNotifier{fail: map[uuid.UUID]bool{flaky.ID: true}}
+	s := &Scheduler{store: store, notifier: notifier, hour: 9, daysBefore: 7}
+
+	s.runOnce(context.Background(), time.Date(2025, 3, 24, 9, 0, 0, 0, time.UTC))
+
+	if !store.today.Equal(today) {
+		t.Fatalf("today = %s, want %s", store.today, today)
+	}
+	if len(notifier.reminders) != 1 || notifier.reminders[0].Subscription.ID != soon.ID {
+		t.Fatalf("reminders = %v, want only the subscription ending in 7 days", notifier.reminders)
+	}
+	if notifier.reminders[0].DaysLeft != 7 {
+		t.Fatalf("days left = %d, want 7", notifier.reminders[0].DaysLeft)
+	}
+
+	// неудачное напоминание повторяется на следующий день, отправленное - нет
+	notifier.fail = nil
+	s.runOnce(context.Background(), time.Date(2025, 3, 25, 9, 0, 0, 0, time.UTC))
+
+	if len(notifier.reminders) != 2 || notifier.reminders[1].Subscription.ID != flaky.ID {
+		t.Fatalf("reminders = %d, want a retry for the failed subscription", len(notifier.reminders))
+	}
+	if notifier.reminders[1].DaysLeft != 3 {
+		t.Fatalf("days left = %d, want 3", notifier.reminders[1].DaysLeft)
+	}
+}
+
+func TestNextRun(t *testing.T) {
+	s := &Scheduler{hour: 9, minute: 30}
+
+	tests := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{time.Date(2025, 3, 24, 8, 0, 0, 0, time.UTC), time.Date(2025, 3, 24, 9, 30, 0, 0, time.UTC)},
+		{time.Date(2025, 3, 24, 9, 30, 0, 0, time.UTC), time.Date(2025, 3, 25, 9, 30, 0, 0, time.UTC)},
+		{time.Date(2025, 3, 31, 23, 0, 0, 0, time.UTC), time.Date(2025, 4, 1, 9, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := s.nextRun(tt.now); !got.Equal(tt.want) {
+			t.Errorf("nextRun(%s) = %s, want %s", tt.now, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"fmt"
+	"subscribe_service/internal/entity"
+	"time"
+)
+
+// analyticsFilter строит фильтр аналитики, совпадающий с фильтром итогов
+func analyticsFilter(req *entity.AnalyticsRequest) *filter {
+	f := subscriptionFilter(req.UserID, req.ServiceName, nil, nil)
+	f.tag(req.Tag)
+	f.category(req.Category)
+	return f
+}
+
+// GetMRR возвращает MRR за каждый месяц периода. Начисления берутся из
+// chargesSQL, как в итогах, изменение к прошлому месяцу считается через LAG.
+// В режиме ByService ряд строится для каждого сервиса, у которого были начисления
+// в периоде, включая месяцы с нулевой выручкой
+func (r *Repository) GetMRR(req *entity.AnalyticsRequest) ([]*entity.MRRPoint, error) {
+	from, to := time.Time(*req.StartDate), time.Time(*req.FinishDate)
+
+	f := analyticsFilter(req)
+	db := r.reader()
+	charges, err := chargesSQL(db, f, from, to)
+	if err != nil {
+		return nil, err
+	}
+	months := `SELECT m.month::date AS month FROM generate_series(` + f.arg(from) + `::date, ` + f.arg(to) + `::date, interval '1 month') AS m(month)`
+
+	spine := `SELECT month, ''::text AS service_name FROM months`
+	join := `c.month = sp.month`
+	if req.ByService {
+		spine = `SELECT m.month, s.service_name FROM months m CROSS JOIN (SELECT DISTINCT service_name FROM charges) s`
+		join = `c.month = sp.month AND c.service_name = sp.service_name`
+	}
+
+	q := `WITH charges AS (` + charges + `),
+			months AS (` + months + `),
+			spine AS (` + spine + `),
+			mrr AS (
+				SELECT sp.month, sp.service_name,
+					COALESCE(SUM(c.charge), 0) AS mrr,
+					COUNT(c.subscription_id) FILTER (WHERE c.charge > 0) AS subscriptions
+				FROM spine sp
+				LEFT JOIN charges c ON ` + join + `
+				GROUP BY sp.month, sp.service_name
+			)
+			SELECT month, service_name, mrr, subscriptions,
+				mrr - LAG(mrr) OVER (PARTITION BY service_name ORDER BY month) AS change
+			FROM mrr
+			ORDER BY month, service_name`
+
+	points := []*entity.MRRPoint{}
+	if err := db.Select(&points, q, f.args...); err != nil {
+		return nil, fmt.Errorf("db GetMRR error: %w", err)
+	}
+
+	return points, nil
+}
+
+// GetChurnRate возвращает отток за каждый месяц периода. Число подписок на начало
+// месяца - накопленная оконной функцией разница начавшихся и закончившихся
+// в предыдущих месяцах. Подписка, начавшаяся и закончившаяся в одном месяце,
+// в отток не входит, так как не действовала на его начало
+func (r *Repository) GetChurnRate(req *entity.AnalyticsRequest) ([]*entity.ChurnRatePoint, error) {
+	f := analyticsFilter(req)
+	where := f.where()
+	fromArg := f.arg(time.Time(*req.StartDate))
+	toArg := f.arg(time.Time(*req.FinishDate))
+
+	q := `WITH subs AS (
+				SELECT date_trunc('month', start_date)::date AS started,
+					date_trunc('month', finish_date)::date AS ended
+				FROM subscriptions` + where + `
+			), events AS (
+				SELECT started AS month, 1 AS started, 0 AS ended, 0 AS churned FROM subs
+				UNION ALL
+				SELECT ended, 0, 1, CASE WHEN ended > started THEN 1 ELSE 0 END FROM subs WHERE ended IS NOT NULL
+			), monthly AS (
+				SELECT m.month::date AS month,
+					COALESCE(SUM(e.started), 0) AS started,
+					COALESCE(SUM(e.ended), 0) AS ended,
+					COALESCE(SUM(e.churned), 0) AS churned
+				FROM generate_series(
+					LEAST((SELECT MIN(started) FROM subs), date_trunc('month', ` + fromArg + `::date)),
+					date_trunc('month', ` + toArg + `::date),
+					interval '1 month'
+				) AS m(month)
+				LEFT JOIN events e ON e.month = m.month::date
+				GROUP BY m.month
+			), running AS (
+				SELECT month, started, churned,
+					COALESCE(SUM(started - ended) OVER (ORDER BY month ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING), 0) AS active_at_start
+				FROM monthly
+			)
+			SELECT month, active_at_start, started, churned,
+				CASE WHEN active_at_start > 0 THEN round(churned::numeric / active_at_start, 4)::float8 ELSE 0 END AS rate
+			FROM running
+			WHERE month >= date_trunc('month', ` + fromArg + `::date)
+			ORDER BY month`
+
+	points := []*entity.ChurnRatePoint{}
+	if err := r.reader().Select(&points, q, f.args...); err != nil {
+		return nil, fmt.Errorf("db GetChurnRate error: %w", err)
+	}
+
+	return points, nil
+}
+
+// GetCohorts возвращает удержание подписок по месяцу начала. Когорта - подписки,
+// начавшиеся в одном месяце периода. Подписка удержана в месяце, если её
+// finish_date не раньше этого месяца. Размер когорты - значение в нулевом месяце,
+// взятое оконной функцией FIRST_VALUE
+func (r *Repository) GetCohorts(req *entity.AnalyticsRequest) ([]*entity.CohortRow, error) {
+	f := analyticsFilter(req)
+	f.add("start_date >= date_trunc('month', $%d::date)", time.Time(*req.StartDate))
+	f.add("start_date < date_trunc('month', $%d::date) + interval '1 month'", time.Time(*req.FinishDate))
+	to := f.arg(time.Time(*req.FinishDate))
+
+	q := `WITH subs AS (
+				SELECT date_trunc('month', start_date)::date AS cohort,
+					date_trunc('month', finish_date)::date AS ended
+				FROM subscriptions` + f.where() + `
+			), cells AS (
+				SELECT s.cohort, m.month::date AS month,
+					COUNT(*) FILTER (WHERE s.ended IS NULL OR s.ended >= m.month) AS retained
+				FROM subs s
+				CROSS JOIN LATERAL generate_series(s.cohort, date_trunc('month', ` + to + `::date), interval '1 month') AS m(month)
+				GROUP BY s.cohort, m.month
+			)
+			SELECT cohort, retained,
+				FIRST_VALUE(retained) OVER (PARTITION BY cohort ORDER BY month) AS size
+			FROM cells
+			ORDER BY cohort, month`
+
+	var cells []struct {
+		Cohort   entity.CustomDate `db:"cohort"`
+		Retained uint              `db:"retained"`
+		Size     uint              `db:"size"`
+	}
+	if err := r.reader().Select(&cells, q, f.args...); err != nil {
+		return nil, fmt.Errorf("db GetCohorts error: %w", err)
+	}
+
+	rows := []*entity.CohortRow{}
+	var row *entity.CohortRow
+	for _, cell := range cells {
+		if row == nil || !time.Time(row.Cohort).Equal(time.Time(cell.Cohort)) {
+			row = &entity.CohortRow{Cohort: cell.Cohort, Size: cell.Size}
+			rows = append(rows, row)
+		}
+		row.Retained = append(row.Retained, cell.Retained)
+	}
+
+	return rows, nil
+}
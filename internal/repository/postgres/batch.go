@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"subscribe_service/internal/entity"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	errBatchRolledBack = errors.New("batch rolled back")
+	// errBatchOverBudget отменяет вставку созданий одним INSERT, если они превышают
+	// бюджеты: тогда превышение проверяется по каждой подписке отдельно
+	errBatchOverBudget = errors.New("batch exceeds budgets")
+)
+
+// Batch выполняет операции в одной транзакции. Сначала все создания вставляются
+// одним многострочным INSERT, затем выполняются обновления и удаления в порядке
+// запроса. Обновления проверяются в update так же, как в Update. Бюджеты, которые
+// превышает создание или обновление, передаются в check, ошибка которой отменяет
+// операцию. Каждая операция выполняется в своей точке сохранения, поэтому ошибка
+// не прерывает транзакцию. В атомарном режиме первая ошибка откатывает весь пакет,
+// остальные операции получают статус rolled_back
+func (r *Repository) Batch(ops []*entity.BatchOperation, atomic bool, update func(current, sub *entity.Subscription) error,
+	check func(sub *entity.Subscription, exceeded []*entity.BudgetStatus) error) ([]*entity.BatchItemResult, error) {
+	results := make([]*entity.BatchItemResult, len(ops))
+	for i, op := range ops {
+		results[i] = &entity.BatchItemResult{Index: i, Op: op.Op}
+	}
+
+	setResult := func(i int, sub *entity.Subscription, err error) error {
+		if err != nil {
+			results[i].Status = entity.BatchStatusFailed
+			results[i].Error = err.Error()
+			if atomic {
+				return errBatchRolledBack
+			}
+			return nil
+		}
+		results[i].Status = entity.BatchStatusOK
+		results[i].Subscription = sub
+		return nil
+	}
+
+	err := r.withTx(func(tx *sqlx.Tx) error {
+		var creates []int
+		var subs []*entity.Subscription
+		for i, op := range ops {
+			if op.Op == entity.BatchCreate {
+				sub := *op.Subscription
+				sub.ID = uuid.New()
+				creates = append(creates, i)
+				subs = append(subs, &sub)
+			}
+		}
+
+		if len(subs) > 0 {
+			err := savepoint(tx, func() error {
+				exceeded, err := exceededBudgetsTx(tx, subscriptionBudgetKeys(subs), func() error {
+					return insertSubscriptions(tx, subs)
+				})
+				if err == nil && len(exceeded) > 0 {
+					return errBatchOverBudget
+				}
+				return err
+			})
+			if err == nil {
+				for k, i := range creates {
+					setResult(i, subs[k], nil)
+				}
+			} else {
+				// вставляем по одной, чтобы найти строки с ошибкой или превышением бюджета
+				for k, i := range creates {
+					sub := subs[k]
+					// сервисы, добавленные в каталог откаченной вставкой, добавляются заново
+					sub.ServiceID, sub.ServiceName = ops[i].Subscription.ServiceID, ops[i].Subscription.ServiceName
+					err := savepoint(tx, func() error {
+						return writeSubscriptionTx(tx, sub.UserID, sub.StartDate, func() (*entity.Subscription, error) {
+							return sub, insertSubscriptions(tx, subs[k:k+1])
+						}, check)
+					})
+					if err := setResult(i, subs[k], err); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		for i, op := range ops {
+			var sub *entity.Subscription
+			var err error
+			switch op.Op {
+			case entity.BatchUpdate:
+				sub = op.Subscription
+				err = savepoint(tx, func() error { return updateCheckedTx(tx, sub, update, check) })
+			case entity.BatchDelete:
+				err = savepoint(tx, func() error {
+					sub, err = deleteTx(tx, op.ID)
+					return err
+				})
+			default:
+				continue
+			}
+
+			if err := setResult(i, sub, err); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if errors.Is(err, errBatchRolledBack) {
+		for _, result := range results {
+			if result.Status != entity.BatchStatusFailed {
+				result.Status = entity.BatchStatusRolledBack
+				result.Subscription = nil
+			}
+		}
+		return results, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// insertSubscriptions вставляет подписки одним многострочным INSERT
+// и пишет событие создания каждой из них в outbox. ID подписок задаются заранее.
+// Неизвестные сервисы добавляются в каталог в той же транзакции
+func insertSubscriptions(tx *sqlx.Tx, subs []*entity.Subscription) error {
+	for _, sub := range subs {
+		if err := ensureCatalogServiceTx(tx, &sub.ServiceID, &sub.ServiceName); err != nil {
+			return err
+		}
+	}
+
+	const columns = 9
+	values := make([]string, 0, len(subs))
+	args := make([]any, 0, len(subs)*columns)
+	for i, sub := range subs {
+		n := i * columns
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9))
+		args = append(args, sub.ID, sub.UserID, sub.StartDate, sub.FinishDate, sub.ServiceName, sub.Price, nullUUID(sub.ServiceID), sub.TrialEndDate, sub.BillingPeriod)
+	}
+
+	q := `INSERT INTO subscriptions (id, user_id, start_date, finish_date, service_name, price, service_id, trial_end_date, billing_period)
+			VALUES ` + strings.Join(values, ", ")
+
+	if _, err := tx.Exec(q, args...); err != nil {
+		return fmt.Errorf("db batch inserting error: %w", err)
+	}
+
+	for _, sub := range subs {
+		tags, err := setTagsTx(tx, sub.ID, sub.Tags)
+		if err != nil {
+			return err
+		}
+		sub.Tags = tags
+		sub.Pauses = []*entity.Pause{}
+		sub.PriceChanges = []*entity.PriceChange{}
+		setStatus(sub)
+
+		if err := refreshChargesTx(tx, sub.ID); err != nil {
+			return err
+		}
+		if err := insertHistory(tx, entity.EventSubscriptionCreated, sub); err != nil {
+			return err
+		}
+		if err := insertOutbox(tx, entity.EventSubscriptionCreated, sub); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// savepoint выполняет fn внутри точки сохранения и откатывается к ней при ошибке,
+// оставляя транзакцию пригодной для дальнейших запросов
+func savepoint(tx *sqlx.Tx, fn func() error) error {
+	if _, err := tx.Exec("SAVEPOINT batch_item"); err != nil {
+		return fmt.Errorf("savepoint error: %w", err)
+	}
+
+	if err := fn(); err != nil {
+		if _, rbErr := tx.Exec("ROLLBACK TO SAVEPOINT batch_item"); rbErr != nil {
+			return errors.Join(err, fmt.Errorf("rollback to savepoint error: %w", rbErr))
+		}
+		return err
+	}
+
+	if _, err := tx.Exec("RELEASE SAVEPOINT batch_item"); err != nil {
+		return fmt.Errorf("release savepoint error: %w", err)
+	}
+
+	return nil
+}
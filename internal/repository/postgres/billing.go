@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"fmt"
+	"subscribe_service/internal/entity"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// billingSchema добавляет подпискам период оплаты. Подписки, созданные до его
+// появления, оплачиваются ежемесячно
+const billingSchema = `
+	ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS billing_period VARCHAR(16) NOT NULL DEFAULT 'monthly'
+		CHECK (billing_period IN ('monthly', 'yearly'));
+	`
+
+// priceChangeSchema хранит запланированные смены цены подписок.
+// effective_date - первое число месяца, с которого действует новая цена
+const priceChangeSchema = `
+	CREATE TABLE IF NOT EXISTS subscription_price_changes (
+		subscription_id UUID NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+		effective_date DATE NOT NULL,
+		price INTEGER NOT NULL CHECK (price >= 0),
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+		PRIMARY KEY (subscription_id, effective_date)
+	);
+	`
+
+// attachPriceChanges загружает смены цены подписок одним запросом в порядке
+// месяцев. Подписки без смен получают пустой список
+func attachPriceChanges(q sqlx.Queryer, subs ...*entity.Subscription) error {
+	if len(subs) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(subs))
+	byID := make(map[uuid.UUID][]*entity.Subscription, len(subs))
+	for _, sub := range subs {
+		sub.PriceChanges = []*entity.PriceChange{}
+		if _, ok := byID[sub.ID]; !ok {
+			ids = append(ids, sub.ID.String())
+		}
+		byID[sub.ID] = append(byID[sub.ID], sub)
+	}
+
+	rows, err := q.Queryx(`SELECT subscription_id, effective_date, price, created_at
+			FROM subscription_price_changes
+			WHERE subscription_id = ANY ($1::uuid[])
+			ORDER BY effective_date`, pq.StringArray(ids))
+	if err != nil {
+		return fmt.Errorf("db loading price changes error: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row struct {
+			SubscriptionID uuid.UUID `db:"subscription_id"`
+			entity.PriceChange
+		}
+		if err := rows.StructScan(&row); err != nil {
+			return fmt.Errorf("row scan error: %w", err)
+		}
+		for _, sub := range byID[row.SubscriptionID] {
+			change := row.PriceChange
+			sub.PriceChanges = append(sub.PriceChanges, &change)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("db loading price changes error: %w", err)
+	}
+
+	return nil
+}
+
+// UpdatePriceChanges блокирует подписку и передаёт её в fn вместе со связанными
+// данными. fn проверяет и меняет sub.PriceChanges, после чего смены цены подписки
+// заменяются на новый список, начисления пересчитываются и в outbox пишется
+// событие обновления. Ошибка fn отменяет изменения
+func (r *Repository) UpdatePriceChanges(id uuid.UUID, fn func(sub *entity.Subscription) error) (*entity.Subscription, error) {
+	var sub *entity.Subscription
+	err := r.withTx(func(tx *sqlx.Tx) error {
+		var err error
+		if sub, err = lockSubscriptionTx(tx, id); err != nil {
+			return err
+		}
+
+		if err := fn(sub); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`DELETE FROM subscription_price_changes WHERE subscription_id = $1`, sub.ID); err != nil {
+			return fmt.Errorf("db clearing price changes error: %w", err)
+		}
+		for _, change := range sub.PriceChanges {
+			q := `INSERT INTO subscription_price_changes (subscription_id, effective_date, price, created_at)
+					VALUES ($1, $2, $3, $4)`
+			if _, err := tx.Exec(q, sub.ID, change.EffectiveDate, change.Price, change.CreatedAt); err != nil {
+				return fmt.Errorf("db inserting price change error: %w", err)
+			}
+		}
+
+		if err := refreshChargesTx(tx, sub.ID); err != nil {
+			return err
+		}
+		if err := insertHistory(tx, entity.EventSubscriptionUpdated, sub); err != nil {
+			return err
+		}
+		return insertOutbox(tx, entity.EventSubscriptionUpdated, sub)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
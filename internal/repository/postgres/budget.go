@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"subscribe_service/internal/entity"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// budgetSchema хранит месячные бюджеты пользователей. Пустая категория и NULL
+// в service_id означают бюджет на все подписки пользователя
+const budgetSchema = `
+	CREATE TABLE IF NOT EXISTS budgets (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		user_id UUID NOT NULL,
+		category VARCHAR(64) NOT NULL DEFAULT '',
+		service_id UUID REFERENCES services (id) ON DELETE CASCADE,
+		amount INTEGER NOT NULL CHECK (amount >= 0),
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+		CONSTRAINT single_budget_scope CHECK (category = '' OR service_id IS NULL)
+	);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS budgets_scope_idx
+		ON budgets (user_id, lower(category), COALESCE(service_id, '00000000-0000-0000-0000-000000000000'));
+	`
+
+// budgetColumns - колонки бюджета b с названием сервиса из каталога s
+const budgetColumns = `b.id, b.user_id, b.category, b.service_id, COALESCE(s.name, '') AS service_name, b.amount, b.created_at`
+
+func (r *Repository) AddBudget(budget *entity.Budget) (*entity.Budget, error) {
+	q := `WITH b AS (
+				INSERT INTO budgets (user_id, category, service_id, amount)
+				VALUES ($1, $2, $3, $4)
+				RETURNING *
+			)
+			SELECT ` + budgetColumns + ` FROM b LEFT JOIN services s ON s.id = b.service_id`
+
+	var resp entity.Budget
+	err := r.db.Get(&resp, q, budget.UserID, budget.Category, nullUUID(budget.ServiceID), budget.Amount)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("budget with the same category and service already exists: %w", ErrConflict)
+		}
+		return nil, fmt.Errorf("db inserting budget error: %w", err)
+	}
+
+	return &resp, nil
+}
+
+func (r *Repository) ListBudgets(userID uuid.UUID) ([]*entity.Budget, error) {
+	q := `SELECT ` + budgetColumns + `
+			FROM budgets b
+			LEFT JOIN services s ON s.id = b.service_id
+			WHERE b.user_id = $1
+			ORDER BY lower(b.category), lower(s.name) NULLS FIRST, b.created_at`
+
+	budgets := []*entity.Budget{}
+	if err := r.db.Select(&budgets, q, userID); err != nil {
+		return nil, fmt.Errorf("db ListBudgets error: %w", err)
+	}
+
+	return budgets, nil
+}
+
+// UpdateBudget меняет бюджет budget.ID пользователя budget.UserID
+func (r *Repository) UpdateBudget(budget *entity.Budget) (*entity.Budget, error) {
+	q := `WITH b AS (
+				UPDATE budgets SET category = $3, service_id = $4, amount = $5
+				WHERE id = $1 AND user_id = $2
+				RETURNING *
+			)
+			SELECT ` + budgetColumns + ` FROM b LEFT JOIN services s ON s.id = b.service_id`
+
+	var resp entity.Budget
+	err := r.db.Get(&resp, q, budget.ID, budget.UserID, budget.Category, nullUUID(budget.ServiceID), budget.Amount)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("budget id %s: %w", budget.ID, ErrIDNotFound)
+		}
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("budget with the same category and service already exists: %w", ErrConflict)
+		}
+		return nil, fmt.Errorf("db updating budget error: %w", err)
+	}
+
+	return &resp, nil
+}
+
+func (r *Repository) DeleteBudget(userID, id uuid.UUID) error {
+	result, err := r.db.Exec(`DELETE FROM budgets WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("db deleting budget error: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("db deleting budget error: %w", err)
+	}
+	if rows == 0 {
+		return ErrIDNotFound
+	}
+
+	return nil
+}
+
+// GetBudgetStatus возвращает бюджеты пользователя с суммой списаний за месяц month,
+// посчитанной так же, как итоги (см. chargesSQL)
+func (r *Repository) GetBudgetStatus(userID uuid.UUID, month time.Time) ([]*entity.BudgetStatus, error) {
+	return budgetStatus(r.db, userID, month)
+}
+
+func budgetStatus(db sqlx.Queryer, userID uuid.UUID, month time.Time) ([]*entity.BudgetStatus, error) {
+	f := subscriptionFilter(userID, "", nil, nil)
+	charges, err := chargesSQL(db, f, month, month)
+	if err != nil {
+		return nil, err
+	}
+
+	q := `WITH ch AS (` + charges + `)
+			SELECT ` + budgetColumns + `, COALESCE(SUM(ch.charge), 0) AS spent
+			FROM budgets b
+			LEFT JOIN services s ON s.id = b.service_id
+			LEFT JOIN ch ON (b.service_id IS NULL OR ch.service_id = b.service_id)
+				AND (b.category = '' OR ch.service_id IN (
+					SELECT id FROM services WHERE lower(category) = lower(b.category)
+				))
+			WHERE b.user_id = ` + f.arg(userID) + `
+			GROUP BY b.id, s.name
+			ORDER BY lower(b.category), lower(s.name) NULLS FIRST, b.created_at`
+
+	statuses := []*entity.BudgetStatus{}
+	if err := sqlx.Select(db, &statuses, q, f.args...); err != nil {
+		return nil, fmt.Errorf("db GetBudgetStatus error: %w", err)
+	}
+	for _, status := range statuses {
+		status.Remaining = int(status.Amount) - int(status.Spent)
+		status.Exceeded = status.Remaining < 0
+	}
+
+	return statuses, nil
+}
+
+// budgetKey - пользователь и месяц, за который проверяются его бюджеты
+type budgetKey struct {
+	UserID uuid.UUID `db:"user_id"`
+	Month  time.Time `db:"month"`
+}
+
+// subscriptionBudgetKeys возвращает без повторов ключи проверки бюджетов подписок subs
+func subscriptionBudgetKeys(subs []*entity.Subscription) []budgetKey {
+	seen := make(map[budgetKey]bool, len(subs))
+	keys := make([]budgetKey, 0, len(subs))
+	for _, sub := range subs {
+		key := budgetKey{UserID: sub.UserID, Month: budgetMonth(sub.StartDate)}
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// budgetMonth возвращает месяц, за который проверяются бюджеты при записи подписки
+// с началом start: текущий, а для ещё не начавшейся подписки - месяц её начала
+func budgetMonth(start entity.CustomDate) time.Time {
+	now := time.Now().UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if t := time.Time(start); t.After(month) {
+		month = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return month
+}
+
+// exceededBudgetsTx выполняет write и возвращает по каждому ключу из keys бюджеты,
+// которые write вывела за лимит: расходы в них выросли и превышают сумму бюджета.
+// Расходы читаются в транзакции tx до и после записи, поэтому учитывают изменения,
+// ещё не видимые другим соединениям
+func exceededBudgetsTx(tx *sqlx.Tx, keys []budgetKey, write func() error) (map[budgetKey][]*entity.BudgetStatus, error) {
+	before := make(map[budgetKey][]*entity.BudgetStatus, len(keys))
+	for _, key := range keys {
+		statuses, err := budgetStatus(tx, key.UserID, key.Month)
+		if err != nil {
+			return nil, err
+		}
+		if len(statuses) > 0 {
+			before[key] = statuses
+		}
+	}
+
+	if err := write(); err != nil {
+		return nil, err
+	}
+
+	exceeded := map[budgetKey][]*entity.BudgetStatus{}
+	for key, old := range before {
+		spent := make(map[uuid.UUID]uint, len(old))
+		for _, status := range old {
+			spent[status.ID] = status.Spent
+		}
+
+		statuses, err := budgetStatus(tx, key.UserID, key.Month)
+		if err != nil {
+			return nil, err
+		}
+		for _, status := range statuses {
+			if was, ok := spent[status.ID]; ok && status.Spent > was && status.Exceeded {
+				exceeded[key] = append(exceeded[key], status)
+			}
+		}
+	}
+
+	return exceeded, nil
+}
+
+// writeSubscriptionTx выполняет запись подписки write и проверяет бюджеты её пользователя
+// userID за месяц budgetMonth(start). Превышенные бюджеты передаются в check вместе
+// с записанной подпиской, ошибка check отменяет запись. По бюджетам пишутся события
+// budget.exceeded
+func writeSubscriptionTx(tx *sqlx.Tx, userID uuid.UUID, start entity.CustomDate, write func() (*entity.Subscription, error),
+	check func(sub *entity.Subscription, exceeded []*entity.BudgetStatus) error) error {
+	key := budgetKey{UserID: userID, Month: budgetMonth(start)}
+
+	var sub *entity.Subscription
+	exceeded, err := exceededBudgetsTx(tx, []budgetKey{key}, func() error {
+		var err error
+		sub, err = write()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := check(sub, exceeded[key]); err != nil {
+		return err
+	}
+	return insertBudgetAlerts(tx, sub, exceeded[key])
+}
+
+// insertBudgetAlerts пишет в outbox событие budget.exceeded по каждому бюджету
+// из alerts, превышенному подпиской sub. При импорте sub равна nil
+func insertBudgetAlerts(tx *sqlx.Tx, sub *entity.Subscription, alerts []*entity.BudgetStatus) error {
+	for _, alert := range alerts {
+		event := &entity.Event{
+			ID:         uuid.New(),
+			Type:       entity.EventBudgetExceeded,
+			OccurredAt: time.Now().UTC(),
+			Data:       sub,
+			Budget:     alert,
+		}
+		if err := insertEvent(tx, alert.ID, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
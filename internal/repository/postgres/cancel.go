@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"fmt"
+	"subscribe_service/internal/entity"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// cancelSchema хранит причины отмены подписок. Строка удаляется,
+// если у подписки снова убирают дату окончания. effective_date - день, по который
+// подписка действует, а finish_date подписки остаётся месяцем. Раньше день
+// окончания записывался в finish_date, такие подписки переводятся на новую схему
+const cancelSchema = `
+	CREATE TABLE IF NOT EXISTS subscription_cancellations (
+		subscription_id UUID PRIMARY KEY REFERENCES subscriptions (id) ON DELETE CASCADE,
+		reason VARCHAR(32) NOT NULL,
+		comment TEXT NOT NULL DEFAULT '',
+		effective VARCHAR(16) NOT NULL,
+		cancelled_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+
+	ALTER TABLE subscription_cancellations ADD COLUMN IF NOT EXISTS effective_date DATE;
+
+	UPDATE subscription_cancellations sc SET effective_date = s.finish_date
+	FROM subscriptions s
+	WHERE sc.effective_date IS NULL AND s.id = sc.subscription_id;
+
+	UPDATE subscriptions SET finish_date = date_trunc('month', finish_date)
+	WHERE finish_date <> date_trunc('month', finish_date)
+		AND id IN (SELECT subscription_id FROM subscription_cancellations);
+
+	ALTER TABLE subscription_cancellations ALTER COLUMN effective_date SET NOT NULL;
+
+	CREATE INDEX IF NOT EXISTS subscription_cancellations_reason_idx
+		ON subscription_cancellations (reason);
+	`
+
+// Cancel блокирует подписку и передаёт её в fn вместе со связанными данными.
+// fn проверяет запрос и заполняет sub.FinishDate, sub.Cancellation с EffectiveDate и при необходимости
+// обрезает sub.Pauses. Изменения сохраняются вместе с событием отмены в outbox
+func (r *Repository) Cancel(id uuid.UUID, fn func(sub *entity.Subscription) error) (*entity.Subscription, error) {
+	var sub *entity.Subscription
+	err := r.withTx(func(tx *sqlx.Tx) error {
+		var err error
+		if sub, err = lockSubscriptionTx(tx, id); err != nil {
+			return err
+		}
+
+		if err := fn(sub); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`UPDATE subscriptions SET finish_date = $2 WHERE id = $1`, id, sub.FinishDate); err != nil {
+			return fmt.Errorf("db cancelling subscription %s error: %w", id, err)
+		}
+
+		if err := replacePausesTx(tx, sub); err != nil {
+			return err
+		}
+
+		q := `INSERT INTO subscription_cancellations (subscription_id, reason, comment, effective, effective_date)
+				VALUES ($1, $2, $3, $4, $5)
+				ON CONFLICT (subscription_id) DO UPDATE
+					SET reason = EXCLUDED.reason, comment = EXCLUDED.comment, effective = EXCLUDED.effective,
+						effective_date = EXCLUDED.effective_date, cancelled_at = now()
+				RETURNING cancelled_at`
+		c := sub.Cancellation
+		if err := tx.Get(&c.CancelledAt, q, id, c.Reason, c.Comment, c.Effective, c.EffectiveDate); err != nil {
+			return fmt.Errorf("db inserting cancellation error: %w", err)
+		}
+		setStatus(sub)
+
+		if err := refreshChargesTx(tx, sub.ID); err != nil {
+			return err
+		}
+		if err := insertHistory(tx, entity.EventSubscriptionCancelled, sub); err != nil {
+			return err
+		}
+		return insertOutbox(tx, entity.EventSubscriptionCancelled, sub)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// attachCancellations загружает сведения об отмене подписок одним запросом
+func attachCancellations(q sqlx.Queryer, subs ...*entity.Subscription) error {
+	if len(subs) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(subs))
+	byID := make(map[uuid.UUID][]*entity.Subscription, len(subs))
+	for _, sub := range subs {
+		sub.Cancellation = nil
+		if _, ok := byID[sub.ID]; !ok {
+			ids = append(ids, sub.ID.String())
+		}
+		byID[sub.ID] = append(byID[sub.ID], sub)
+	}
+
+	rows, err := q.Queryx(`SELECT subscription_id, reason, comment, effective, effective_date, cancelled_at
+			FROM subscription_cancellations
+			WHERE subscription_id = ANY ($1::uuid[])`, pq.StringArray(ids))
+	if err != nil {
+		return fmt.Errorf("db loading cancellations error: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row struct {
+			SubscriptionID uuid.UUID `db:"subscription_id"`
+			entity.Cancellation
+		}
+		if err := rows.StructScan(&row); err != nil {
+			return fmt.Errorf("row scan error: %w", err)
+		}
+		for _, sub := range byID[row.SubscriptionID] {
+			cancellation := row.Cancellation
+			sub.Cancellation = &cancellation
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("db loading cancellations error: %w", err)
+	}
+
+	return nil
+}
+
+// GetChurn считает отмены по месяцу окончания подписки, причине и сервису
+func (r *Repository) GetChurn(req *entity.ChurnRequest) ([]*entity.ChurnItem, error) {
+	f := subscriptionFilter(req.UserID, req.ServiceName, nil, nil)
+	if req.StartDate != nil && !time.Time(*req.StartDate).IsZero() {
+		f.add("finish_date >= date_trunc('month', $%d::date)", time.Time(*req.StartDate))
+	}
+	if req.FinishDate != nil && !time.Time(*req.FinishDate).IsZero() {
+		f.add("finish_date < date_trunc('month', $%d::date) + interval '1 month'", time.Time(*req.FinishDate))
+	}
+
+	q := `SELECT date_trunc('month', finish_date)::date AS month, reason, service_name,
+				COUNT(*) AS count, COALESCE(SUM(price), 0) AS lost_revenue
+			FROM subscription_cancellations c
+			JOIN subscriptions s ON s.id = c.subscription_id` + f.where() + `
+			GROUP BY 1, 2, 3
+			ORDER BY 1, count DESC, 2, 3`
+
+	items := []*entity.ChurnItem{}
+	if err := r.db.Select(&items, q, f.args...); err != nil {
+		return nil, fmt.Errorf("db GetChurn error: %w", err)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,299 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"subscribe_service/internal/entity"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// ErrConflict означает, что изменение противоречит данным в базе:
+// название или псевдоним сервиса уже заняты или сервис используется подписками
+var ErrConflict = fmt.Errorf("conflict")
+
+// catalogSchema создаёт каталог сервисов, связывает с ним подписки
+// и заполняет service_id у подписок, созданных до появления каталога.
+// Заполнение идемпотентно и при повторных запусках ничего не меняет.
+// service_names хранит названия и псевдонимы всех сервисов в нижнем регистре
+// и не даёт двум сервисам претендовать на одно имя. Таблицу поддерживает триггер,
+// из уже совпадающих имён при заполнении остаётся имя более старого сервиса
+const catalogSchema = `
+	CREATE TABLE IF NOT EXISTS services (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		name VARCHAR(255) NOT NULL,
+		aliases TEXT[] NOT NULL DEFAULT '{}',
+		category TEXT NOT NULL DEFAULT '',
+		default_price INTEGER NOT NULL DEFAULT 0 CHECK (default_price >= 0),
+		currency CHAR(3) NOT NULL DEFAULT 'RUB',
+		website TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS services_name_idx ON services (lower(name));
+
+	CREATE TABLE IF NOT EXISTS service_names (
+		name TEXT PRIMARY KEY,
+		service_id UUID NOT NULL REFERENCES services (id) ON DELETE CASCADE
+	);
+
+	CREATE OR REPLACE FUNCTION sync_service_names() RETURNS trigger AS $$
+	BEGIN
+		DELETE FROM service_names WHERE service_id = NEW.id;
+		INSERT INTO service_names (name, service_id)
+		SELECT DISTINCT lower(n), NEW.id FROM unnest(array_append(NEW.aliases, NEW.name)) n;
+		RETURN NEW;
+	END
+	$$ LANGUAGE plpgsql;
+
+	DROP TRIGGER IF EXISTS services_sync_names ON services;
+	CREATE TRIGGER services_sync_names AFTER INSERT OR UPDATE OF name, aliases ON services
+		FOR EACH ROW EXECUTE FUNCTION sync_service_names();
+
+	INSERT INTO service_names (name, service_id)
+	SELECT DISTINCT ON (lower(n)) lower(n), s.id
+	FROM services s, unnest(array_append(s.aliases, s.name)) n
+	ORDER BY lower(n), s.created_at
+	ON CONFLICT DO NOTHING;
+
+	ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS service_id UUID REFERENCES services (id);
+
+	CREATE INDEX IF NOT EXISTS subscriptions_service_id_idx ON subscriptions (service_id);
+
+	INSERT INTO services (name)
+	SELECT DISTINCT ON (lower(btrim(s.service_name))) btrim(s.service_name)
+	FROM subscriptions s
+	WHERE s.service_id IS NULL
+		AND NOT EXISTS (
+			SELECT 1 FROM services
+			WHERE lower(name) = lower(btrim(s.service_name))
+				OR lower(btrim(s.service_name)) = ANY (SELECT lower(a) FROM unnest(aliases) a)
+		)
+	ORDER BY lower(btrim(s.service_name)), btrim(s.service_name)
+	ON CONFLICT DO NOTHING;
+
+	UPDATE subscriptions s
+	SET service_id = c.id, service_name = c.name
+	FROM services c
+	WHERE s.service_id IS NULL
+		AND (lower(c.name) = lower(btrim(s.service_name))
+			OR lower(btrim(s.service_name)) = ANY (SELECT lower(a) FROM unnest(c.aliases) a));
+	`
+
+// serviceMatchSQL - условие совпадения сервиса каталога с именем без учёта
+// регистра, по названию или по псевдониму. Вместо %[1]d подставляется номер параметра
+const serviceMatchSQL = `(lower(name) = lower($%[1]d) OR lower($%[1]d) = ANY (SELECT lower(a) FROM unnest(aliases) a))`
+
+const catalogServiceColumns = "id, name, aliases, category, default_price, currency, website, created_at"
+
+// catalogServiceRow нужен для чтения псевдонимов из TEXT[]
+type catalogServiceRow struct {
+	entity.CatalogService
+	Aliases pq.StringArray `db:"aliases"`
+}
+
+func (row *catalogServiceRow) toEntity() *entity.CatalogService {
+	svc := row.CatalogService
+	svc.Aliases = []string(row.Aliases)
+	if svc.Aliases == nil {
+		svc.Aliases = []string{}
+	}
+	return &svc
+}
+
+// nullUUID возвращает NULL для пустого UUID, чтобы не нарушать внешний ключ
+func nullUUID(id uuid.UUID) any {
+	if id == uuid.Nil {
+		return nil
+	}
+	return id
+}
+
+func (r *Repository) AddCatalogService(req *entity.CreateCatalogServiceRequest) (*entity.CatalogService, error) {
+	q := `INSERT INTO services (name, aliases, category, default_price, currency, website)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING ` + catalogServiceColumns
+
+	var row catalogServiceRow
+	err := r.db.Get(&row, q, req.Name, pq.StringArray(req.Aliases), req.Category, req.DefaultPrice, req.Currency, req.Website)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("name or alias of service %q is already used by another service: %w", req.Name, ErrConflict)
+		}
+		return nil, fmt.Errorf("db inserting service error: %w", err)
+	}
+
+	return row.toEntity(), nil
+}
+
+func (r *Repository) GetCatalogService(id uuid.UUID) (*entity.CatalogService, error) {
+	q := `SELECT ` + catalogServiceColumns + ` FROM services WHERE id = $1`
+
+	var row catalogServiceRow
+	if err := r.db.Get(&row, q, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("service id %s: %w", id, ErrIDNotFound)
+		}
+		return nil, fmt.Errorf("db GetCatalogService error: %w", err)
+	}
+
+	return row.toEntity(), nil
+}
+
+// FindCatalogService ищет сервис по названию или псевдониму без учёта регистра.
+// Совпадение по названию важнее совпадения по псевдониму. Если сервис не найден, возвращает nil
+func (r *Repository) FindCatalogService(name string) (*entity.CatalogService, error) {
+	q := fmt.Sprintf(`SELECT `+catalogServiceColumns+`
+			FROM services
+			WHERE `+serviceMatchSQL+`
+			ORDER BY lower(name) = lower($1) DESC, created_at
+			LIMIT 1`, 1)
+
+	var row catalogServiceRow
+	if err := r.db.Get(&row, q, name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("db FindCatalogService error: %w", err)
+	}
+
+	return row.toEntity(), nil
+}
+
+func (r *Repository) ListCatalogServices(category string) ([]*entity.CatalogService, error) {
+	q := `SELECT ` + catalogServiceColumns + ` FROM services`
+
+	f := &filter{}
+	if category != "" {
+		f.add("lower(category) = lower($%d)", category)
+	}
+	q += f.where() + " ORDER BY lower(name)"
+
+	var rows []catalogServiceRow
+	if err := r.db.Select(&rows, q, f.args...); err != nil {
+		return nil, fmt.Errorf("db ListCatalogServices error: %w", err)
+	}
+
+	services := make([]*entity.CatalogService, 0, len(rows))
+	for i := range rows {
+		services = append(services, rows[i].toEntity())
+	}
+
+	return services, nil
+}
+
+// UpdateCatalogService обновляет сервис и переносит новое название в его подписки.
+// Переименованные подписки получают снимок истории и событие обновления в outbox
+// в той же транзакции, как при обычном изменении подписки
+func (r *Repository) UpdateCatalogService(svc *entity.CatalogService) (*entity.CatalogService, error) {
+	var row catalogServiceRow
+	err := r.withTx(func(tx *sqlx.Tx) error {
+		q := `UPDATE services
+				SET name = $2, aliases = $3, category = $4, default_price = $5, currency = $6, website = $7
+				WHERE id = $1
+				RETURNING ` + catalogServiceColumns
+
+		err := tx.Get(&row, q, svc.ID, svc.Name, pq.StringArray(svc.Aliases), svc.Category, svc.DefaultPrice, svc.Currency, svc.Website)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("service id %s: %w", svc.ID, ErrIDNotFound)
+			}
+			if isUniqueViolation(err) {
+				return fmt.Errorf("name or alias of service %q is already used by another service: %w", svc.Name, ErrConflict)
+			}
+			return fmt.Errorf("db updating service error: %w", err)
+		}
+
+		q = `UPDATE subscriptions SET service_name = $2
+				WHERE service_id = $1 AND service_name <> $2
+				RETURNING ` + subscriptionColumns
+		var renamed []*entity.Subscription
+		if err := tx.Select(&renamed, q, row.ID, row.Name); err != nil {
+			return fmt.Errorf("db renaming subscriptions error: %w", err)
+		}
+
+		if err := attachRelations(tx, renamed...); err != nil {
+			return err
+		}
+		setStatus(renamed...)
+
+		for _, sub := range renamed {
+			if err := insertHistory(tx, entity.EventSubscriptionUpdated, sub); err != nil {
+				return err
+			}
+			if err := insertOutbox(tx, entity.EventSubscriptionUpdated, sub); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return row.toEntity(), nil
+}
+
+// DeleteCatalogService удаляет сервис, если на него не ссылается ни одна подписка
+func (r *Repository) DeleteCatalogService(id uuid.UUID) error {
+	result, err := r.db.Exec(`DELETE FROM services WHERE id = $1`, id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return fmt.Errorf("service is used by subscriptions: %w", ErrConflict)
+		}
+		return fmt.Errorf("db deleting service error: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("db deleting service error: %w", err)
+	}
+	if rows == 0 {
+		return ErrIDNotFound
+	}
+
+	return nil
+}
+
+// ensureCatalogServiceTx проставляет подписке без service_id сервис каталога
+// с названием или псевдонимом name и его каноническое название. Если такого сервиса
+// нет, он добавляется в каталог в транзакции tx и откатывается вместе с подпиской
+func ensureCatalogServiceTx(tx *sqlx.Tx, id *uuid.UUID, name *string) error {
+	if *id != uuid.Nil {
+		return nil
+	}
+
+	find := `SELECT s.id, s.name FROM service_names n
+			JOIN services s ON s.id = n.service_id
+			WHERE n.name = lower($1)`
+	// сервис, добавленный параллельной транзакцией, виден только следующему запросу,
+	// поэтому после вставки без результата поиск повторяется
+	queries := []string{find, `INSERT INTO services (name) VALUES ($1) ON CONFLICT DO NOTHING RETURNING id, name`, find}
+
+	for _, q := range queries {
+		var svc entity.CatalogService
+		err := tx.Get(&svc, q, *name)
+		if err == nil {
+			*id, *name = svc.ID, svc.Name
+			return nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			if isUniqueViolation(err) {
+				return fmt.Errorf("service name %q is already used by another service: %w", *name, ErrConflict)
+			}
+			return fmt.Errorf("db ensuring service %q error: %w", *name, err)
+		}
+	}
+
+	return fmt.Errorf("service %q can't be added to catalog", *name)
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
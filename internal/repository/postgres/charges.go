@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"subscribe_service/internal/entity"
+	"time"
+)
+
+// monthlyChargesSQL возвращает подзапрос, разворачивающий подписки, подходящие
+// под f, в помесячные начисления за месяцы с from по to включительно.
+// Колонки: subscription_id, user_id, service_id, service_name, month, charge.
+// Подписка начисляется за каждый месяц от start_date до finish_date включительно,
+// месяцы пробного периода до trial_end_date дают нулевое начисление,
+// месяцы паузы не начисляются вовсе. Годовая подписка начисляется только
+// в первый платный месяц и в те же месяцы следующих лет. Цена месяца берётся
+// из последней смены цены не позже него, без смен - из price
+func monthlyChargesSQL(f *filter, from, to time.Time) string {
+	fromArg := f.arg(from)
+	toArg := f.arg(to)
+
+	where := f.where()
+	if where == "" {
+		where = " WHERE "
+	} else {
+		where += " AND "
+	}
+	where += `NOT EXISTS (
+				SELECT 1 FROM subscription_pauses p
+				WHERE p.subscription_id = s.id
+					AND m.month >= p.start_date
+					AND (p.end_date IS NULL OR m.month <= p.end_date)
+			)`
+
+	return `SELECT s.id AS subscription_id, s.user_id, s.service_id, s.service_name, m.month::date AS month,
+				CASE
+					WHEN m.month < date_trunc('month', s.trial_end_date) THEN 0
+					WHEN s.billing_period = '` + entity.BillingYearly + `' AND date_part('month', age(m.month,
+						date_trunc('month', COALESCE(s.trial_end_date, s.start_date)))) <> 0 THEN 0
+					ELSE COALESCE((
+						SELECT pc.price FROM subscription_price_changes pc
+						WHERE pc.subscription_id = s.id AND pc.effective_date <= m.month
+						ORDER BY pc.effective_date DESC
+						LIMIT 1
+					), s.price)
+				END AS charge
+			FROM subscriptions s
+			CROSS JOIN LATERAL generate_series(
+				GREATEST(date_trunc('month', s.start_date), date_trunc('month', ` + fromArg + `::date)),
+				LEAST(date_trunc('month', COALESCE(s.finish_date, ` + toArg + `::date)), date_trunc('month', ` + toArg + `::date)),
+				interval '1 month'
+			) AS m(month)` + where
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"strings"
+	"subscribe_service/internal/config"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// buildDSN возвращает строку подключения к основной базе. DATABASE_URL
+// используется как есть, иначе строка собирается из отдельных параметров
+func buildDSN(cfg *config.Config) string {
+	if cfg.DbURL != "" {
+		return cfg.DbURL
+	}
+
+	params := []struct{ key, value string }{
+		{"host", cfg.DbHost},
+		{"port", cfg.DbPort},
+		{"dbname", cfg.DbName},
+		{"user", cfg.DbUser},
+		{"password", cfg.DbPassword},
+		{"sslmode", cfg.DbSSLMode},
+		{"sslrootcert", cfg.DbSSLRootCert},
+		{"application_name", cfg.DbApplicationName},
+	}
+
+	parts := make([]string, 0, len(params))
+	for _, p := range params {
+		if p.value != "" {
+			parts = append(parts, p.key+"="+quoteDSNValue(p.value))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// quoteDSNValue заключает значение в кавычки, чтобы пароль с пробелами
+// или кавычками не ломал строку подключения
+func quoteDSNValue(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `'`, `\'`)
+	return "'" + v + "'"
+}
+
+// configurePool применяет к пулу соединений ограничения из конфигурации.
+// Нулевые значения оставляют значения database/sql по умолчанию
+func configurePool(db *sqlx.DB, cfg *config.Config) {
+	if cfg.DbMaxOpenConns > 0 {
+		db.SetMaxOpenConns(cfg.DbMaxOpenConns)
+	}
+	if cfg.DbMaxIdleConns > 0 {
+		db.SetMaxIdleConns(cfg.DbMaxIdleConns)
+	}
+	db.SetConnMaxLifetime(cfg.DbConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.DbConnMaxIdleTime)
+}
+
+// connectDB подключается к базе, повторяя попытки с экспоненциально растущей
+// задержкой со случайным разбросом, чтобы несколько экземпляров приложения
+// не переподключались одновременно. Возвращает ошибку последней попытки
+func connectDB(cfg *config.Config) (*sqlx.DB, error) {
+	attempts := max(cfg.DbConnectAttempts, 1)
+
+	var lastErr error
+	for i := 1; i <= attempts; i++ {
+		db, err := sqlx.Connect("postgres", buildDSN(cfg))
+		if err == nil {
+			log.Printf("Connected to DB after %d attempts", i)
+			configurePool(db, cfg)
+			return db, nil
+		}
+		lastErr = err
+		if i == attempts {
+			break
+		}
+
+		delay := connectBackoff(cfg.DbConnectBackoff, cfg.DbConnectBackoffMax, i)
+		log.Printf("connecting to DB attempt %d failed: %s, retrying in %s", i, err, delay)
+		time.Sleep(delay)
+	}
+
+	return nil, fmt.Errorf("unable to connect to DB after %d attempts: %w", attempts, lastErr)
+}
+
+// connectBackoff возвращает задержку перед попыткой attempt+1: base * 2^(attempt-1),
+// но не больше maxDelay, из которой случайно выбирается значение от половины до полной
+func connectBackoff(base, maxDelay time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxDelay)
+
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"fmt"
+	"subscribe_service/internal/entity"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// exportFetchSize - количество строк, читаемых из курсора за раз
+const exportFetchSize = 1000
+
+// Export читает подписки через серверный курсор порциями по exportFetchSize
+// и передаёт их по одной в fn, поэтому расход памяти не зависит от размера таблицы
+func (r *Repository) Export(req *entity.ListRequest, fn func(sub *entity.Subscription) error) error {
+	q := `DECLARE export_cursor NO SCROLL CURSOR FOR
+			SELECT ` + subscriptionColumns + `
+			FROM subscriptions`
+
+	f := subscriptionFilter(req.UserID, req.ServiceName, req.StartDate, req.FinishDate)
+	f.tag(req.Tag)
+	f.category(req.Category)
+	f.status(req.Status, req.AsOf)
+	f.activeAt(req.AsOf)
+	q += f.where() + " ORDER BY start_date, id"
+
+	return inTx(r.reader(), func(tx *sqlx.Tx) error {
+		if _, err := tx.Exec(q, f.args...); err != nil {
+			return fmt.Errorf("declare export cursor error: %w", err)
+		}
+
+		fetch := fmt.Sprintf("FETCH %d FROM export_cursor", exportFetchSize)
+		for {
+			rows, err := tx.Queryx(fetch)
+			if err != nil {
+				return fmt.Errorf("fetch export cursor error: %w", err)
+			}
+
+			subs := make([]*entity.Subscription, 0, exportFetchSize)
+			for rows.Next() {
+				var sub entity.Subscription
+				if err := rows.StructScan(&sub); err != nil {
+					rows.Close()
+					return fmt.Errorf("row scan error: %w", err)
+				}
+				subs = append(subs, &sub)
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return fmt.Errorf("fetch export cursor error: %w", err)
+			}
+
+			if err := attachRelations(tx, subs...); err != nil {
+				return err
+			}
+			for _, sub := range subs {
+				if err := fn(sub); err != nil {
+					return err
+				}
+			}
+
+			if len(subs) < exportFetchSize {
+				return nil
+			}
+		}
+	})
+}
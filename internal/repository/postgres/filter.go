@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"subscribe_service/internal/entity"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// filter накапливает условия WHERE и аргументы запроса к ним
+type filter struct {
+	conditions []string
+	args       []any
+}
+
+// add добавляет условие. Вместо %d в условие подставляется номер параметра
+func (f *filter) add(condition string, arg any) {
+	f.args = append(f.args, arg)
+	f.conditions = append(f.conditions, fmt.Sprintf(condition, len(f.args)))
+}
+
+// arg добавляет аргумент без условия и возвращает его плейсхолдер
+func (f *filter) arg(v any) string {
+	f.args = append(f.args, v)
+	return fmt.Sprintf("$%d", len(f.args))
+}
+
+// where возвращает WHERE-часть запроса или пустую строку, если условий нет
+func (f *filter) where() string {
+	if len(f.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(f.conditions, " AND ")
+}
+
+// subscriptionFilter строит фильтр по общим полям подписки. Пустые значения игнорируются.
+// Сервис ищется без учёта регистра, в том числе по псевдонимам из каталога
+func subscriptionFilter(userID uuid.UUID, serviceName string, startDate, finishDate *entity.CustomDate) *filter {
+	f := &filter{}
+	if userID != uuid.Nil {
+		f.add("user_id = $%d", userID)
+	}
+
+	if serviceName != "" {
+		f.add("(lower(service_name) = lower($%[1]d) OR service_id IN (SELECT id FROM services WHERE "+serviceMatchSQL+"))", serviceName)
+	}
+
+	if startDate != nil && !time.Time(*startDate).IsZero() {
+		f.add("start_date >= $%d", time.Time(*startDate))
+	}
+
+	if finishDate != nil && !time.Time(*finishDate).IsZero() {
+		f.add("finish_date <= $%d", time.Time(*finishDate))
+	}
+
+	return f
+}
+
+// tag оставляет подписки с тегом name без учёта регистра
+func (f *filter) tag(name string) {
+	if name != "" {
+		f.add(`id IN (SELECT st.subscription_id FROM subscription_tags st
+				JOIN tags t ON t.id = st.tag_id WHERE lower(t.name) = lower($%d))`, name)
+	}
+}
+
+// category оставляет подписки на сервисы каталога из категории name без учёта регистра
+func (f *filter) category(name string) {
+	if name != "" {
+		f.add("service_id IN (SELECT id FROM services WHERE lower(category) = lower($%d))", name)
+	}
+}
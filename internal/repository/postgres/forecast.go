@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"fmt"
+	"subscribe_service/internal/entity"
+	"time"
+)
+
+// GetForecast возвращает ожидаемые списания за каждый из req.Months месяцев
+// начиная с req.From. Списания считаются тем же разворотом по месяцам, что
+// и итоги (см. chargesSQL), поэтому прогноз и история совпадают.
+// Месяцы без списаний входят в ряд с нулями
+func (r *Repository) GetForecast(req *entity.ForecastRequest) ([]*entity.ForecastMonth, error) {
+	from := time.Time(req.From)
+	to := from.AddDate(0, req.Months-1, 0)
+
+	f := subscriptionFilter(req.UserID, req.ServiceName, nil, nil)
+	charges, err := chargesSQL(r.db, f, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	q := `SELECT m.month::date AS month,
+				COALESCE(SUM(c.charge), 0) AS total,
+				COUNT(DISTINCT c.subscription_id) AS count,
+				SUM(COALESCE(SUM(c.charge), 0)) OVER (ORDER BY m.month) AS cumulative
+			FROM generate_series(` + f.arg(from) + `::date, ` + f.arg(to) + `::date, interval '1 month') AS m(month)
+			LEFT JOIN (` + charges + `) c ON c.month = m.month::date
+			GROUP BY m.month
+			ORDER BY m.month`
+
+	months := []*entity.ForecastMonth{}
+	if err := r.db.Select(&months, q, f.args...); err != nil {
+		return nil, fmt.Errorf("db GetForecast error: %w", err)
+	}
+
+	return months, nil
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"subscribe_service/internal/entity"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// historySchema хранит снимки подписки после каждого изменения. История не зависит
+// от outbox, поэтому outbox можно очищать. При первом запуске таблица заполняется
+// снимками из событий подписок, уже записанных в outbox, а подписки без событий
+// получают снимок в backfillHistory.
+// Удалённые подписки сохраняют историю, поэтому внешнего ключа нет
+const historySchema = `
+	CREATE TABLE IF NOT EXISTS subscription_history (
+		id BIGSERIAL PRIMARY KEY,
+		subscription_id UUID NOT NULL,
+		event_type TEXT NOT NULL,
+		data JSONB NOT NULL,
+		recorded_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+
+	CREATE INDEX IF NOT EXISTS subscription_history_subscription_idx ON subscription_history (subscription_id, id);
+
+	INSERT INTO subscription_history (subscription_id, event_type, data, recorded_at)
+	SELECT aggregate_id, event_type, payload->'data', created_at
+	FROM outbox
+	WHERE event_type LIKE 'subscription.%'
+		AND NOT EXISTS (SELECT 1 FROM subscription_history)
+	ORDER BY id;
+	`
+
+// historyBackfillLockKey - ключ advisory lock, под которым реплики сервиса
+// по очереди дописывают недостающие снимки истории
+const historyBackfillLockKey = 7_240_002
+
+// backfillHistory записывает снимок текущего состояния подписок, у которых нет
+// истории: созданных до появления subscription_history или чьи события уже
+// удалены из outbox. Снимок датирован -infinity, поэтому такие подписки видны
+// в истории на любой момент в текущем виде. Новые подписки получают снимок
+// при создании, так что повторный запуск ничего не записывает
+func backfillHistory(db *sqlx.DB) (int, error) {
+	var subs []*entity.Subscription
+	err := inTx(db, func(tx *sqlx.Tx) error {
+		if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, historyBackfillLockKey); err != nil {
+			return fmt.Errorf("advisory lock error: %w", err)
+		}
+
+		q := `SELECT ` + subscriptionColumns + `
+				FROM subscriptions s
+				WHERE NOT EXISTS (SELECT 1 FROM subscription_history h WHERE h.subscription_id = s.id)`
+		subs = nil
+		if err := tx.Select(&subs, q); err != nil {
+			return fmt.Errorf("db selecting subscriptions without history error: %w", err)
+		}
+
+		if err := attachRelations(tx, subs...); err != nil {
+			return err
+		}
+		setStatus(subs...)
+
+		for _, sub := range subs {
+			data, err := json.Marshal(sub)
+			if err != nil {
+				return fmt.Errorf("marshalling history error: %w", err)
+			}
+
+			q := `INSERT INTO subscription_history (subscription_id, event_type, data, recorded_at)
+					VALUES ($1, $2, $3, '-infinity')`
+			if _, err := tx.Exec(q, sub.ID, entity.EventSubscriptionCreated, data); err != nil {
+				return fmt.Errorf("insert history error: %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(subs), nil
+}
+
+// insertHistory записывает снимок подписки sub после изменения eventType
+// в subscription_history в рамках транзакции tx
+func insertHistory(tx sqlx.Execer, eventType string, sub *entity.Subscription) error {
+	data, err := json.Marshal(sub)
+	if err != nil {
+		return fmt.Errorf("marshalling history error: %w", err)
+	}
+
+	q := `INSERT INTO subscription_history (subscription_id, event_type, data) VALUES ($1, $2, $3)`
+	if _, err := tx.Exec(q, sub.ID, eventType, data); err != nil {
+		return fmt.Errorf("insert history error: %w", err)
+	}
+
+	return nil
+}
+
+// historySQL возвращает CTE history с колонками subscriptionColumns и data -
+// подписками в том виде, в каком они были до момента at по последним снимкам.
+// Удалённые к этому моменту подписки и подписки, созданные после at, не входят в history
+func historySQL(at string) string {
+	return `WITH snapshots AS (
+				SELECT DISTINCT ON (subscription_id) subscription_id, event_type, data
+				FROM subscription_history
+				WHERE recorded_at < ` + at + `
+				ORDER BY subscription_id, id DESC
+			), history AS (
+				SELECT subscription_id AS id,
+					(data->>'user_id')::uuid AS user_id,
+					to_date(data->>'start_date', 'MM-YYYY') AS start_date,
+					to_date(data->>'finish_date', 'MM-YYYY') AS finish_date,
+					data->>'service_name' AS service_name,
+					(data->>'price')::integer AS price,
+					NULLIF(data->>'service_id', '` + uuid.Nil.String() + `')::uuid AS service_id,
+					to_date(data->>'trial_end_date', 'MM-YYYY') AS trial_end_date,
+					COALESCE(data->>'billing_period', '` + entity.BillingMonthly + `') AS billing_period,
+					data
+				FROM snapshots
+				WHERE event_type <> '` + entity.EventSubscriptionDeleted + `'
+			) `
+}
+
+// GetRecordAt возвращает подписку в том виде, в каком она была до момента at.
+// Если до at снимков подписки нет или она была удалена, возвращает ErrIDNotFound
+func (r *Repository) GetRecordAt(id uuid.UUID, at time.Time) (*entity.Subscription, error) {
+	q := `SELECT event_type, data
+			FROM subscription_history
+			WHERE subscription_id = $1 AND recorded_at < $2
+			ORDER BY id DESC
+			LIMIT 1`
+
+	var snapshot struct {
+		EventType string `db:"event_type"`
+		Data      []byte `db:"data"`
+	}
+	err := r.reader().Get(&snapshot, q, id, at)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("subscription id %s has no history before %s: %w", id, at.Format(time.DateOnly), ErrIDNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting history of record %s error: %w", id, err)
+	}
+	if snapshot.EventType == entity.EventSubscriptionDeleted {
+		return nil, fmt.Errorf("subscription id %s was deleted before %s: %w", id, at.Format(time.DateOnly), ErrIDNotFound)
+	}
+
+	var sub entity.Subscription
+	if err := json.Unmarshal(snapshot.Data, &sub); err != nil {
+		return nil, fmt.Errorf("unmarshalling history of record %s error: %w", id, err)
+	}
+
+	return &sub, nil
+}
+
+// activeAt оставляет подписки, действовавшие в месяце asOf
+func (f *filter) activeAt(asOf *entity.CustomDate) {
+	if asOf == nil || time.Time(*asOf).IsZero() {
+		return
+	}
+	f.add(`(date_trunc('month', start_date) <= date_trunc('month', $%[1]d::date)
+			AND (finish_date IS NULL OR date_trunc('month', finish_date) >= date_trunc('month', $%[1]d::date)))`, time.Time(*asOf))
+}
+
+// monthEnd возвращает начало месяца, следующего за месяцем t
+func monthEnd(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"fmt"
+	"subscribe_service/internal/entity"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Import загружает подписки через COPY во временную таблицу и переносит их
+// в subscriptions одной транзакцией. fn получает функцию add и передаёт в неё
+// подписки по мере чтения, поэтому весь набор не хранится в памяти.
+// Подписки, полностью совпадающие с существующими или друг с другом,
+// пропускаются. Для каждой созданной подписки в outbox пишется событие.
+// Бюджеты, которые превышает импорт, передаются в check с пустой подпиской,
+// ошибка check отменяет импорт. По ним пишутся события budget.exceeded
+func (r *Repository) Import(fn func(add func(sub *entity.Subscription) error) error,
+	check func(sub *entity.Subscription, exceeded []*entity.BudgetStatus) error) (staged, created int, err error) {
+	err = r.withTx(func(tx *sqlx.Tx) error {
+		q := `CREATE TEMP TABLE import_subscriptions (
+				user_id UUID NOT NULL,
+				start_date DATE NOT NULL,
+				finish_date DATE,
+				service_name VARCHAR(255) NOT NULL,
+				price INTEGER NOT NULL,
+				service_id UUID,
+				trial_end_date DATE,
+				billing_period VARCHAR(16) NOT NULL
+			) ON COMMIT DROP`
+		if _, err := tx.Exec(q); err != nil {
+			return fmt.Errorf("creating import table error: %w", err)
+		}
+
+		stmt, err := tx.Prepare(pq.CopyIn("import_subscriptions", "user_id", "start_date", "finish_date", "service_name", "price", "service_id", "trial_end_date", "billing_period"))
+		if err != nil {
+			return fmt.Errorf("prepare copy error: %w", err)
+		}
+		defer stmt.Close()
+
+		err = fn(func(sub *entity.Subscription) error {
+			if _, err := stmt.Exec(sub.UserID, sub.StartDate, sub.FinishDate, sub.ServiceName, sub.Price, nullUUID(sub.ServiceID), sub.TrialEndDate, sub.BillingPeriod); err != nil {
+				return fmt.Errorf("copy row error: %w", err)
+			}
+			staged++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// вызов Exec без аргументов завершает COPY
+		if _, err := stmt.Exec(); err != nil {
+			return fmt.Errorf("copy error: %w", err)
+		}
+		if err := stmt.Close(); err != nil {
+			return fmt.Errorf("copy error: %w", err)
+		}
+
+		// бюджеты проверяются только у пользователей, для которых они заданы
+		var keys []budgetKey
+		q = `SELECT DISTINCT user_id, greatest($1::date, date_trunc('month', start_date)::date) AS month
+				FROM import_subscriptions
+				WHERE user_id IN (SELECT user_id FROM budgets)`
+		if err := tx.Select(&keys, q, budgetMonth(entity.CustomDate{})); err != nil {
+			return fmt.Errorf("db reading import budgets error: %w", err)
+		}
+
+		exceeded, err := exceededBudgetsTx(tx, keys, func() error {
+			var err error
+			created, err = insertImported(tx)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		var alerts []*entity.BudgetStatus
+		for _, key := range keys {
+			alerts = append(alerts, exceeded[key]...)
+		}
+		if err := check(nil, alerts); err != nil {
+			return err
+		}
+		return insertBudgetAlerts(tx, nil, alerts)
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return staged, created, nil
+}
+
+// insertImported переносит подписки из import_subscriptions в subscriptions, пропуская
+// повторы, и возвращает число созданных. Сервисы без service_id ищутся в каталоге
+// или добавляются в него. ID созданных подписок сохраняются в import_created
+func insertImported(tx *sqlx.Tx) (int, error) {
+	q := `CREATE TEMP TABLE import_created (id UUID PRIMARY KEY) ON COMMIT DROP`
+	if _, err := tx.Exec(q); err != nil {
+		return 0, fmt.Errorf("creating import table error: %w", err)
+	}
+
+	// неизвестные сервисы добавляются в каталог в транзакции импорта
+	q = `INSERT INTO services (name)
+			SELECT DISTINCT ON (lower(i.service_name)) i.service_name
+			FROM import_subscriptions i
+			WHERE i.service_id IS NULL
+				AND NOT EXISTS (SELECT 1 FROM service_names n WHERE n.name = lower(i.service_name))
+			ORDER BY lower(i.service_name), i.service_name
+			ON CONFLICT DO NOTHING`
+	if _, err := tx.Exec(q); err != nil {
+		if isUniqueViolation(err) {
+			return 0, fmt.Errorf("imported service name is already used by another service: %w", ErrConflict)
+		}
+		return 0, fmt.Errorf("db adding imported services error: %w", err)
+	}
+
+	q = `UPDATE import_subscriptions i
+			SET service_id = s.id, service_name = s.name
+			FROM service_names n
+			JOIN services s ON s.id = n.service_id
+			WHERE i.service_id IS NULL AND n.name = lower(i.service_name)`
+	if _, err := tx.Exec(q); err != nil {
+		return 0, fmt.Errorf("db resolving imported services error: %w", err)
+	}
+
+	q = `WITH inserted AS (
+			INSERT INTO subscriptions (user_id, start_date, finish_date, service_name, price, service_id, trial_end_date, billing_period)
+			SELECT DISTINCT i.user_id, i.start_date, i.finish_date, i.service_name, i.price, i.service_id, i.trial_end_date, i.billing_period
+			FROM import_subscriptions i
+			WHERE NOT EXISTS (
+				SELECT 1 FROM subscriptions s
+				WHERE s.user_id = i.user_id
+					AND s.service_name = i.service_name
+					AND s.start_date = i.start_date
+					AND s.finish_date IS NOT DISTINCT FROM i.finish_date
+					AND s.price = i.price
+			)
+			RETURNING id
+		)
+		INSERT INTO import_created SELECT id FROM inserted`
+
+	result, err := tx.Exec(q)
+	if err != nil {
+		return 0, fmt.Errorf("db importing error: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("db importing error: %w", err)
+	}
+
+	imported := `s.id IN (SELECT id FROM import_created)`
+	if err := refreshCharges(tx, &filter{conditions: []string{imported}},
+		`subscription_id IN (SELECT id FROM import_created)`); err != nil {
+		return 0, err
+	}
+
+	return int(rows), insertImportEvents(tx)
+}
+
+// importEventsPage - сколько созданных подписок читается за раз при записи событий
+const importEventsPage = 1000
+
+// insertImportEvents пишет в outbox событие о создании каждой подписки
+// из import_created. Подписки читаются страницами, чтобы не держать весь
+// импорт в памяти
+func insertImportEvents(tx *sqlx.Tx) error {
+	q := `SELECT ` + subscriptionColumns + `
+			FROM subscriptions s
+			WHERE s.id IN (SELECT id FROM import_created) AND s.id > $1
+			ORDER BY s.id
+			LIMIT $2`
+
+	last := uuid.Nil
+	for {
+		var subs []*entity.Subscription
+		if err := tx.Select(&subs, q, last, importEventsPage); err != nil {
+			return fmt.Errorf("db reading imported subscriptions error: %w", err)
+		}
+
+		for _, sub := range subs {
+			sub.Tags = []string{}
+			sub.Pauses = []*entity.Pause{}
+			sub.PriceChanges = []*entity.PriceChange{}
+			setStatus(sub)
+
+			if err := insertHistory(tx, entity.EventSubscriptionCreated, sub); err != nil {
+				return err
+			}
+			if err := insertOutbox(tx, entity.EventSubscriptionCreated, sub); err != nil {
+				return err
+			}
+		}
+
+		if len(subs) < importEventsPage {
+			return nil
+		}
+		last = subs[len(subs)-1].ID
+	}
+}
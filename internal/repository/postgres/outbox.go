@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"subscribe_service/internal/entity"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const outboxSchema = `
+	CREATE TABLE IF NOT EXISTS outbox (
+		id BIGSERIAL PRIMARY KEY,
+		event_id UUID NOT NULL UNIQUE,
+		event_type TEXT NOT NULL,
+		aggregate_id UUID NOT NULL,
+		payload JSONB NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		published_at TIMESTAMPTZ
+	);
+
+	ALTER TABLE outbox ADD COLUMN IF NOT EXISTS claimed_until TIMESTAMPTZ;
+
+	CREATE INDEX IF NOT EXISTS outbox_unpublished_idx ON outbox (id) WHERE published_at IS NULL;
+
+	CREATE TABLE IF NOT EXISTS outbox_offsets (
+		publisher TEXT PRIMARY KEY,
+		last_id BIGINT NOT NULL DEFAULT 0,
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+	`
+
+// withTx выполняет fn в транзакции и откатывает её при ошибке
+func (r *Repository) withTx(fn func(tx *sqlx.Tx) error) error {
+	return inTx(r.db, fn)
+}
+
+// inTx выполняет fn в транзакции базы db
+func inTx(db *sqlx.DB, fn func(tx *sqlx.Tx) error) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("begin transaction error: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction error: %w", err)
+	}
+
+	return nil
+}
+
+// insertOutbox записывает событие об изменении подписки в outbox в рамках транзакции tx
+func insertOutbox(tx sqlx.Execer, eventType string, sub *entity.Subscription) error {
+	return insertEvent(tx, sub.ID, &entity.Event{
+		ID:         uuid.New(),
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+		Data:       sub,
+	})
+}
+
+// insertEvent записывает событие в outbox в рамках транзакции tx. aggregateID -
+// объект, к которому относится событие: подписка или бюджет для budget.exceeded
+func insertEvent(tx sqlx.Execer, aggregateID uuid.UUID, event *entity.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshalling event error: %w", err)
+	}
+
+	q := `INSERT INTO outbox (event_id, event_type, aggregate_id, payload)
+			VALUES ($1, $2, $3, $4)`
+
+	if _, err := tx.Exec(q, event.ID, event.Type, aggregateID, payload); err != nil {
+		return fmt.Errorf("insert outbox event error: %w", err)
+	}
+
+	return nil
+}
+
+// outboxClaimTTL - время, на которое событие закрепляется за диспетчером.
+// Если диспетчер не отметил событие за это время, его заберёт следующий
+const outboxClaimTTL = 5 * time.Minute
+
+// ProcessOutbox закрепляет до limit неопубликованных событий в короткой
+// транзакции, затем вне транзакции по порядку передаёт их в publish и
+// во второй транзакции отмечает опубликованными все события до первой ошибки.
+// Остальные закреплённые события освобождаются. Смещение последнего
+// опубликованного события сохраняется для publisher. Возвращает количество
+// опубликованных событий
+func (r *Repository) ProcessOutbox(publisher string, limit int, publish func(event *entity.Event) error) (int, error) {
+	q := `UPDATE outbox SET claimed_until = now() + $2 * interval '1 second'
+			WHERE id IN (
+				SELECT id FROM outbox
+				WHERE published_at IS NULL AND (claimed_until IS NULL OR claimed_until < now())
+				ORDER BY id
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, payload`
+
+	var rows []struct {
+		ID      int64  `db:"id"`
+		Payload []byte `db:"payload"`
+	}
+	if err := r.db.Select(&rows, q, limit, outboxClaimTTL.Seconds()); err != nil {
+		return 0, fmt.Errorf("db claim outbox error: %w", err)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].ID < rows[j].ID })
+
+	claimed := make([]int64, 0, len(rows))
+	for _, row := range rows {
+		claimed = append(claimed, row.ID)
+	}
+
+	ids := make([]int64, 0, len(rows))
+	var publishErr error
+	for _, row := range rows {
+		var event entity.Event
+		if publishErr = json.Unmarshal(row.Payload, &event); publishErr != nil {
+			publishErr = fmt.Errorf("unmarshalling outbox event %d error: %w", row.ID, publishErr)
+			break
+		}
+
+		if publishErr = publish(&event); publishErr != nil {
+			break
+		}
+		ids = append(ids, row.ID)
+	}
+
+	err := r.withTx(func(tx *sqlx.Tx) error {
+		if len(ids) > 0 {
+			_, err := tx.Exec(`UPDATE outbox SET published_at = now() WHERE id = ANY($1)`, pq.Int64Array(ids))
+			if err != nil {
+				return fmt.Errorf("mark outbox events published error: %w", err)
+			}
+
+			q := `INSERT INTO outbox_offsets (publisher, last_id) VALUES ($1, $2)
+					ON CONFLICT (publisher) DO UPDATE
+					SET last_id = GREATEST(outbox_offsets.last_id, EXCLUDED.last_id), updated_at = now()`
+			if _, err := tx.Exec(q, publisher, ids[len(ids)-1]); err != nil {
+				return fmt.Errorf("update outbox offset error: %w", err)
+			}
+		}
+
+		if len(claimed) > 0 {
+			_, err := tx.Exec(`UPDATE outbox SET claimed_until = NULL WHERE id = ANY($1)`, pq.Int64Array(claimed))
+			if err != nil {
+				return fmt.Errorf("release outbox events error: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if publishErr != nil {
+		return len(ids), fmt.Errorf("publish outbox event error: %w", publishErr)
+	}
+
+	return len(ids), nil
+}
@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"subscribe_service/internal/entity"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// pauseSchema хранит приостановки подписок. Даты - первые числа месяцев,
+// end_date включительно, NULL означает паузу до возобновления
+const pauseSchema = `
+	CREATE TABLE IF NOT EXISTS subscription_pauses (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		subscription_id UUID NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+		start_date DATE NOT NULL,
+		end_date DATE,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+		CONSTRAINT valid_pause_range CHECK (end_date IS NULL OR end_date >= start_date)
+	);
+
+	CREATE INDEX IF NOT EXISTS subscription_pauses_subscription_idx
+		ON subscription_pauses (subscription_id, start_date);
+	`
+
+// attachRelations загружает теги, паузы, смены цены и сведения об отмене подписок
+// и вычисляет по ним статус на текущий месяц
+func attachRelations(q sqlx.Queryer, subs ...*entity.Subscription) error {
+	if err := attachTags(q, subs...); err != nil {
+		return err
+	}
+	if err := attachPauses(q, subs...); err != nil {
+		return err
+	}
+	if err := attachPriceChanges(q, subs...); err != nil {
+		return err
+	}
+	if err := attachCancellations(q, subs...); err != nil {
+		return err
+	}
+	setStatus(subs...)
+	return nil
+}
+
+// attachPauses загружает паузы подписок одним запросом в порядке начала.
+// Подписки без пауз получают пустой список
+func attachPauses(q sqlx.Queryer, subs ...*entity.Subscription) error {
+	if len(subs) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(subs))
+	byID := make(map[uuid.UUID][]*entity.Subscription, len(subs))
+	for _, sub := range subs {
+		sub.Pauses = []*entity.Pause{}
+		if _, ok := byID[sub.ID]; !ok {
+			ids = append(ids, sub.ID.String())
+		}
+		byID[sub.ID] = append(byID[sub.ID], sub)
+	}
+
+	rows, err := q.Queryx(`SELECT subscription_id, id, start_date, end_date, created_at
+			FROM subscription_pauses
+			WHERE subscription_id = ANY ($1::uuid[])
+			ORDER BY start_date`, pq.StringArray(ids))
+	if err != nil {
+		return fmt.Errorf("db loading pauses error: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row struct {
+			SubscriptionID uuid.UUID `db:"subscription_id"`
+			entity.Pause
+		}
+		if err := rows.StructScan(&row); err != nil {
+			return fmt.Errorf("row scan error: %w", err)
+		}
+		for _, sub := range byID[row.SubscriptionID] {
+			pause := row.Pause
+			sub.Pauses = append(sub.Pauses, &pause)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("db loading pauses error: %w", err)
+	}
+
+	return nil
+}
+
+// UpdatePauses блокирует подписку и передаёт её в fn вместе со связанными данными.
+// fn проверяет и меняет sub.Pauses, после чего паузы подписки заменяются на
+// новый список и в outbox пишется событие eventType. Ошибка fn отменяет изменения
+func (r *Repository) UpdatePauses(id uuid.UUID, eventType string, fn func(sub *entity.Subscription) error) (*entity.Subscription, error) {
+	var sub *entity.Subscription
+	err := r.withTx(func(tx *sqlx.Tx) error {
+		var err error
+		if sub, err = lockSubscriptionTx(tx, id); err != nil {
+			return err
+		}
+
+		if err := fn(sub); err != nil {
+			return err
+		}
+
+		if err := replacePausesTx(tx, sub); err != nil {
+			return err
+		}
+		setStatus(sub)
+
+		if err := refreshChargesTx(tx, sub.ID); err != nil {
+			return err
+		}
+		if err := insertHistory(tx, eventType, sub); err != nil {
+			return err
+		}
+		return insertOutbox(tx, eventType, sub)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// lockSubscriptionTx читает подписку со связанными данными, блокируя её строку до конца транзакции
+func lockSubscriptionTx(tx *sqlx.Tx, id uuid.UUID) (*entity.Subscription, error) {
+	var sub entity.Subscription
+	q := `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE id = $1 FOR UPDATE`
+	if err := tx.Get(&sub, q, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("subscription id %s: %w", id, ErrIDNotFound)
+		}
+		return nil, fmt.Errorf("db locking subscription %s error: %w", id, err)
+	}
+
+	if err := attachRelations(tx, &sub); err != nil {
+		return nil, err
+	}
+
+	return &sub, nil
+}
+
+// replacePausesTx заменяет паузы подписки на sub.Pauses
+func replacePausesTx(tx *sqlx.Tx, sub *entity.Subscription) error {
+	if _, err := tx.Exec(`DELETE FROM subscription_pauses WHERE subscription_id = $1`, sub.ID); err != nil {
+		return fmt.Errorf("db clearing pauses error: %w", err)
+	}
+
+	q := `INSERT INTO subscription_pauses (id, subscription_id, start_date, end_date, created_at)
+			VALUES ($1, $2, $3, $4, COALESCE($5, now()))
+			RETURNING created_at`
+	for _, pause := range sub.Pauses {
+		var createdAt any
+		if !pause.CreatedAt.IsZero() {
+			createdAt = pause.CreatedAt
+		}
+		if err := tx.Get(&pause.CreatedAt, q, pause.ID, sub.ID, pause.StartDate, pause.EndDate, createdAt); err != nil {
+			return fmt.Errorf("db inserting pause error: %w", err)
+		}
+	}
+
+	return nil
+}
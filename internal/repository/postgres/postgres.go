@@ -2,10 +2,10 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
-	"strings"
 	"subscribe_service/internal/config"
 	"subscribe_service/internal/entity"
 	"time"
@@ -17,14 +17,18 @@ import (
 
 var ErrIDNotFound = fmt.Errorf("id not found")
 
+// subscriptionColumns - колонки подписки, которые читаются в entity.Subscription
+const subscriptionColumns = "id, user_id, start_date, finish_date, service_name, price, service_id, trial_end_date, billing_period"
+
 type Repository struct {
 	db *sqlx.DB
+	// replicas - реплики для чтения подписок, итогов, выгрузки и аналитики.
+	// nil, если реплики не настроены или репозиторий получен через Primary
+	replicas *replicaSet
 }
 
 func NewRepository(cfg *config.Config) *Repository {
-	dsn := fmt.Sprintf("host=%s dbname=%s user=%s password=%s sslmode=disable", cfg.DbHost, cfg.DbName, cfg.DbUser, cfg.DbPassword)
-
-	db, err := connectDB(dsn, cfg.DbConnectAttempts)
+	db, err := connectDB(cfg)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -45,171 +49,382 @@ func NewRepository(cfg *config.Config) *Repository {
 	if err != nil {
 		log.Fatal("creating table error: ", err)
 	}
-	return &Repository{db: db}
-}
 
-func (r *Repository) Add(sub *entity.CreateRequest) (*entity.Subscription, error) {
-	q := `INSERT INTO subscriptions (user_id, start_date, finish_date, service_name, price)
-			VALUES (:UserID, :StartDate, :FinishDate, :ServiceName, :Price)
-			RETURNING id, user_id, start_date, finish_date, service_name, price`
+	for _, schema := range []string{webhookSchema, outboxSchema, reminderSchema, catalogSchema, tagSchema, searchSchema, trialSchema, billingSchema, priceChangeSchema, pauseSchema, cancelSchema, historySchema, budgetSchema, rollupSchema} {
+		if _, err := db.Exec(schema); err != nil {
+			log.Fatal("creating table error: ", err)
+		}
+	}
 
-	params := map[string]any{
-		"UserID":      sub.UserID,
-		"StartDate":   sub.StartDate,
-		"FinishDate":  sub.FinishDate,
-		"ServiceName": sub.ServiceName,
-		"Price":       sub.Price,
+	if n, err := backfillHistory(db); err != nil {
+		log.Fatal("backfilling history error: ", err)
+	} else if n > 0 {
+		log.Printf("history backfilled for %d subscriptions\n", n)
 	}
 
-	rows, err := r.db.NamedQuery(q, params)
-	if err != nil {
-		return nil, fmt.Errorf("db inserting error: %w", err)
+	repo := &Repository{db: db}
+	if len(cfg.DbReplicaDSNs) > 0 {
+		repo.replicas = openReplicas(cfg)
 	}
-	defer rows.Close()
 
-	var resp entity.Subscription
+	return repo
+}
 
-	if rows.Next() {
-		err = rows.Scan(&resp.ID, &resp.UserID, &resp.StartDate, &resp.FinishDate, &resp.ServiceName, &resp.Price)
-		if err != nil {
-			return nil, fmt.Errorf("scanning id error: %w", err)
-		}
-	} else {
-		return nil, fmt.Errorf("no rows returned from insert")
+// Primary возвращает репозиторий, который и читает, и пишет в основную базу.
+// Нужен для чтения перед записью и для запросов, которым нельзя отставать от записи
+func (r *Repository) Primary() *Repository {
+	return &Repository{db: r.db}
+}
+
+// reader возвращает базу для чтения: доступную реплику или основную базу
+func (r *Repository) reader() *sqlx.DB {
+	if r.replicas == nil {
+		return r.db
+	}
+	return r.replicas.pick(r.db)
+}
+
+// Add создаёт подписку. Сервис без service_id ищется в каталоге или добавляется
+// в него в той же транзакции (см. ensureCatalogServiceTx). Бюджеты, которые она превышает, проверяются в той же
+// транзакции и передаются в check, ошибка которой отменяет создание. По превышенным
+// бюджетам в outbox пишутся события budget.exceeded
+func (r *Repository) Add(sub *entity.CreateRequest, check func(sub *entity.Subscription, exceeded []*entity.BudgetStatus) error) (*entity.Subscription, error) {
+	q := `INSERT INTO subscriptions (user_id, start_date, finish_date, service_name, price, service_id, trial_end_date, billing_period)
+			VALUES (:UserID, :StartDate, :FinishDate, :ServiceName, :Price, :ServiceID, :TrialEndDate, :BillingPeriod)
+			RETURNING ` + subscriptionColumns
+
+	var resp entity.Subscription
+	err := r.withTx(func(tx *sqlx.Tx) error {
+		return writeSubscriptionTx(tx, sub.UserID, sub.StartDate, func() (*entity.Subscription, error) {
+			if err := ensureCatalogServiceTx(tx, &sub.ServiceID, &sub.ServiceName); err != nil {
+				return nil, err
+			}
+
+			params := map[string]any{
+				"UserID":        sub.UserID,
+				"StartDate":     sub.StartDate,
+				"FinishDate":    sub.FinishDate,
+				"ServiceName":   sub.ServiceName,
+				"Price":         sub.Price,
+				"ServiceID":     nullUUID(sub.ServiceID),
+				"TrialEndDate":  sub.TrialEndDate,
+				"BillingPeriod": sub.BillingPeriod,
+			}
+			rows, err := sqlx.NamedQuery(tx, q, params)
+			if err != nil {
+				return nil, fmt.Errorf("db inserting error: %w", err)
+			}
+			defer rows.Close()
+
+			if !rows.Next() {
+				return nil, fmt.Errorf("no rows returned from insert")
+			}
+			if err := rows.StructScan(&resp); err != nil {
+				return nil, fmt.Errorf("scanning id error: %w", err)
+			}
+			rows.Close()
+
+			resp.Tags, err = setTagsTx(tx, resp.ID, sub.Tags)
+			if err != nil {
+				return nil, err
+			}
+			resp.Pauses = []*entity.Pause{}
+			resp.PriceChanges = []*entity.PriceChange{}
+			resp.AllowOverBudget = sub.AllowOverBudget
+			setStatus(&resp)
+
+			if err := refreshChargesTx(tx, resp.ID); err != nil {
+				return nil, err
+			}
+			if err := insertHistory(tx, entity.EventSubscriptionCreated, &resp); err != nil {
+				return nil, err
+			}
+			return &resp, insertOutbox(tx, entity.EventSubscriptionCreated, &resp)
+		}, check)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return &resp, nil
 }
 
 func (r *Repository) GetRecordByID(id uuid.UUID) (*entity.Subscription, error) {
-	q := `SELECT id, user_id, start_date, finish_date, service_name, price
+	q := `SELECT ` + subscriptionColumns + `
 			FROM subscriptions
 			WHERE id = $1`
 
+	db := r.reader()
 	var resp entity.Subscription
-	err := r.db.Get(&resp, q, id)
+	err := db.Get(&resp, q, id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("subscription id %s not found", id)
+			return nil, fmt.Errorf("subscription id %s: %w", id, ErrIDNotFound)
 		}
 		return nil, fmt.Errorf("getting of record %s error: %w", id, err)
 	}
 
+	if err := attachRelations(db, &resp); err != nil {
+		return nil, err
+	}
+
 	return &resp, nil
 }
 
-func (r *Repository) Update(sub *entity.Subscription) (*entity.Subscription, error) {
+// Update обновляет подписку sub.ID в транзакции (см. updateCheckedTx)
+func (r *Repository) Update(sub *entity.Subscription, fn func(current, sub *entity.Subscription) error,
+	check func(sub *entity.Subscription, exceeded []*entity.BudgetStatus) error) (*entity.Subscription, error) {
+	err := r.withTx(func(tx *sqlx.Tx) error {
+		return updateCheckedTx(tx, sub, fn, check)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// updateCheckedTx блокирует подписку sub.ID и передаёт её текущее состояние вместе с sub
+// в fn, которая проверяет изменение. Если fn не вернула ошибку, sub сохраняется,
+// а превышенные изменением бюджеты передаются в check, ошибка которой отменяет
+// обновление. Если у подписки впервые появилась дата окончания, в outbox пишется
+// событие отмены, иначе событие обновления. По бюджетам пишутся события budget.exceeded
+func updateCheckedTx(tx *sqlx.Tx, sub *entity.Subscription, fn func(current, sub *entity.Subscription) error,
+	check func(sub *entity.Subscription, exceeded []*entity.BudgetStatus) error) error {
+	current, err := lockSubscriptionTx(tx, sub.ID)
+	if err != nil {
+		return err
+	}
+	if err := fn(current, sub); err != nil {
+		return err
+	}
+
+	return writeSubscriptionTx(tx, sub.UserID, sub.StartDate, func() (*entity.Subscription, error) {
+		return sub, updateTx(tx, sub)
+	}, check)
+}
+
+func updateTx(tx *sqlx.Tx, sub *entity.Subscription) error {
 	q := `UPDATE subscriptions
 		SET user_id = :UserID,
 			start_date = :StartDate,
 			finish_date = :FinishDate,
 			service_name = :ServiceName,
-			price = :Price
+			price = :Price,
+			service_id = :ServiceID,
+			trial_end_date = :TrialEndDate,
+			billing_period = :BillingPeriod,
+			-- при переносе окончания пробного периода событие конверсии отправляется заново
+			trial_converted_at = CASE WHEN trial_end_date IS NOT DISTINCT FROM :TrialEndDate
+				THEN trial_converted_at END
 		WHERE id = :ID`
 
+	if err := ensureCatalogServiceTx(tx, &sub.ServiceID, &sub.ServiceName); err != nil {
+		return err
+	}
+
 	params := map[string]any{
-		"ID":          sub.ID,
-		"UserID":      sub.UserID,
-		"StartDate":   sub.StartDate,
-		"FinishDate":  sub.FinishDate,
-		"ServiceName": sub.ServiceName,
-		"Price":       sub.Price,
+		"ID":            sub.ID,
+		"UserID":        sub.UserID,
+		"StartDate":     sub.StartDate,
+		"FinishDate":    sub.FinishDate,
+		"ServiceName":   sub.ServiceName,
+		"Price":         sub.Price,
+		"ServiceID":     nullUUID(sub.ServiceID),
+		"TrialEndDate":  sub.TrialEndDate,
+		"BillingPeriod": sub.BillingPeriod,
 	}
 
-	_, err := r.db.NamedExec(q, params)
+	var oldFinishDate *entity.CustomDate
+	err := tx.Get(&oldFinishDate, `SELECT finish_date FROM subscriptions WHERE id = $1 FOR UPDATE`, sub.ID)
 	if err != nil {
-		return nil, fmt.Errorf("update subscription %s error: %w", sub.ID, err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("subscription id %s: %w", sub.ID, ErrIDNotFound)
+		}
+		return fmt.Errorf("update subscription %s error: %w", sub.ID, err)
 	}
 
-	return sub, nil
+	if _, err := tx.NamedExec(q, params); err != nil {
+		return fmt.Errorf("update subscription %s error: %w", sub.ID, err)
+	}
+
+	if sub.Tags != nil {
+		if sub.Tags, err = setTagsTx(tx, sub.ID, sub.Tags); err != nil {
+			return err
+		}
+	} else if err := attachTags(tx, sub); err != nil {
+		return err
+	}
+	if err := attachPauses(tx, sub); err != nil {
+		return err
+	}
+	if err := attachPriceChanges(tx, sub); err != nil {
+		return err
+	}
+
+	// подписка без даты окончания больше не считается отменённой, а при переносе
+	// окончания на другой месяц отмена действует по конец нового месяца
+	if sub.FinishDate == nil || time.Time(*sub.FinishDate).IsZero() {
+		if _, err := tx.Exec(`DELETE FROM subscription_cancellations WHERE subscription_id = $1`, sub.ID); err != nil {
+			return fmt.Errorf("db clearing cancellation error: %w", err)
+		}
+	} else {
+		q := `UPDATE subscription_cancellations
+				SET effective_date = date_trunc('month', $2::date) + interval '1 month - 1 day'
+				WHERE subscription_id = $1 AND date_trunc('month', effective_date) <> date_trunc('month', $2::date)`
+		if _, err := tx.Exec(q, sub.ID, sub.FinishDate); err != nil {
+			return fmt.Errorf("db moving cancellation error: %w", err)
+		}
+	}
+	if err := attachCancellations(tx, sub); err != nil {
+		return err
+	}
+	setStatus(sub)
+
+	eventType := entity.EventSubscriptionUpdated
+	if oldFinishDate == nil && sub.FinishDate != nil && !time.Time(*sub.FinishDate).IsZero() {
+		eventType = entity.EventSubscriptionCancelled
+	}
+	if err := refreshChargesTx(tx, sub.ID); err != nil {
+		return err
+	}
+	if err := insertHistory(tx, eventType, sub); err != nil {
+		return err
+	}
+	return insertOutbox(tx, eventType, sub)
 }
 
 func (r *Repository) Delete(id uuid.UUID) error {
-	q := `DELETE FROM subscriptions WHERE id = $1`
+	return r.withTx(func(tx *sqlx.Tx) error {
+		_, err := deleteTx(tx, id)
+		return err
+	})
+}
 
-	result, err := r.db.Exec(q, id)
-	if err != nil {
-		log.Printf("Can't delete record %s. error: %s", id, err)
-		return fmt.Errorf("delete record %s error: %w", id, err)
+func deleteTx(tx *sqlx.Tx, id uuid.UUID) (*entity.Subscription, error) {
+	// связанные данные удаляются каскадно, поэтому читаются до удаления подписки
+	related := &entity.Subscription{ID: id}
+	if err := attachRelations(tx, related); err != nil {
+		return nil, err
 	}
 
-	if rowsAffected, err := result.RowsAffected(); rowsAffected == 0 {
+	q := `DELETE FROM subscriptions WHERE id = $1
+			RETURNING ` + subscriptionColumns
+
+	var sub entity.Subscription
+	err := tx.Get(&sub, q, id)
+	if errors.Is(err, sql.ErrNoRows) {
 		log.Println("no records found for delete")
-		return ErrIDNotFound
+		return nil, ErrIDNotFound
 	} else if err != nil {
 		log.Printf("Can't delete record %s. error: %s", id, err)
-		return fmt.Errorf("delete record %s error: %w", id, err)
+		return nil, fmt.Errorf("delete record %s error: %w", id, err)
 	}
+	sub.Tags, sub.Pauses, sub.PriceChanges, sub.Cancellation = related.Tags, related.Pauses, related.PriceChanges, related.Cancellation
+	setStatus(&sub)
 
-	return nil
+	if err := insertHistory(tx, entity.EventSubscriptionDeleted, &sub); err != nil {
+		return nil, err
+	}
+	if err := insertOutbox(tx, entity.EventSubscriptionDeleted, &sub); err != nil {
+		return nil, err
+	}
+
+	return &sub, nil
 }
 
-func (r *Repository) List() (*entity.SubscriptionsResponse, error) {
-	q := `SELECT id, user_id, start_date, finish_date, service_name, price
+// List возвращает подписки по фильтрам. С req.AsOf и req.History подписки
+// читаются из истории в том виде, в каком были на конец месяца AsOf
+func (r *Repository) List(req *entity.ListRequest) (*entity.SubscriptionsResponse, error) {
+	f := subscriptionFilter(req.UserID, req.ServiceName, req.StartDate, req.FinishDate)
+	f.tag(req.Tag)
+	f.category(req.Category)
+	f.status(req.Status, req.AsOf)
+	f.activeAt(req.AsOf)
+
+	q := `SELECT ` + subscriptionColumns + `, NULL::jsonb AS data
 			FROM subscriptions`
+	if req.History && req.AsOf != nil && !time.Time(*req.AsOf).IsZero() {
+		q = historySQL(f.arg(monthEnd(time.Time(*req.AsOf)))) + `
+			SELECT ` + subscriptionColumns + `, data
+			FROM history AS subscriptions`
+	}
+	q += f.where()
 
-	rows, err := r.db.Query(q)
+	db := r.reader()
+	rows, err := db.Queryx(q, f.args...)
 	if err != nil {
 		return nil, fmt.Errorf("db List error: %w", err)
 	}
 	defer rows.Close()
 
 	var resp entity.SubscriptionsResponse
+	var current []*entity.Subscription
 	for rows.Next() {
-		var sub entity.Subscription
-		err := rows.Scan(&sub.ID, &sub.UserID, &sub.StartDate, &sub.FinishDate, &sub.ServiceName, &sub.Price)
-		if err != nil {
+		var row struct {
+			entity.Subscription
+			Data []byte `db:"data"`
+		}
+		if err := rows.StructScan(&row); err != nil {
 			return nil, fmt.Errorf("row scan error: %w", err)
 		}
 
-		resp.Subscriptions = append(resp.Subscriptions, &sub)
+		sub := &row.Subscription
+		if row.Data == nil {
+			current = append(current, sub)
+		} else if err := json.Unmarshal(row.Data, sub); err != nil {
+			return nil, fmt.Errorf("unmarshalling history of record %s error: %w", sub.ID, err)
+		}
+		resp.Subscriptions = append(resp.Subscriptions, sub)
+	}
+	rows.Close()
+
+	if err := attachRelations(db, current...); err != nil {
+		return nil, err
 	}
 
 	return &resp, nil
 }
 
+// GetTotal суммирует помесячные списания за период с req.StartDate по req.FinishDate.
+// Без StartDate период начинается с начала каждой подписки. Count - количество
+// подписок, действовавших в периоде, включая пробные
 func (r *Repository) GetTotal(req *entity.TotalRequest) (*entity.TotalResponse, error) {
-	query := `SELECT COALESCE(SUM(price), 0) AS total, COUNT(id) AS count 
-			FROM subscriptions`
-
-	whereConditions := make([]string, 0, 4)
-	args := make([]any, 0, 4)
-	paramCount := 0
-	if req.UserID != uuid.Nil {
-		paramCount++
-		whereConditions = append(whereConditions, fmt.Sprintf("user_id = $%d", paramCount))
-		args = append(args, req.UserID)
+	var from, to time.Time
+	if req.StartDate != nil {
+		from = time.Time(*req.StartDate)
 	}
-
-	if req.ServiceName != "" {
-		paramCount++
-		whereConditions = append(whereConditions, fmt.Sprintf("service_name = $%d", paramCount))
-		args = append(args, req.ServiceName)
+	if req.FinishDate != nil {
+		to = time.Time(*req.FinishDate)
 	}
 
-	if req.StartDate != nil && !time.Time(*req.StartDate).IsZero() {
-		paramCount++
-		whereConditions = append(whereConditions, fmt.Sprintf("start_date >= $%d", paramCount))
-		args = append(args, time.Time(*req.StartDate))
+	f := subscriptionFilter(req.UserID, req.ServiceName, nil, nil)
+	f.tag(req.Tag)
+	f.category(req.Category)
+	db := r.reader()
+	charges, err := chargesSQL(db, f, from, to)
+	if err != nil {
+		return nil, err
 	}
 
-	if req.FinishDate != nil && !time.Time(*req.FinishDate).IsZero() {
-		paramCount++
-		whereConditions = append(whereConditions, fmt.Sprintf("finish_date <= $%d", paramCount))
-		args = append(args, time.Time(*req.FinishDate))
-	}
-
-	if paramCount > 0 {
-		query = query + " WHERE " + strings.Join(whereConditions, " AND ")
-	}
+	query := `SELECT COALESCE(SUM(charge), 0) AS total, COUNT(DISTINCT subscription_id) AS count
+			FROM (` + charges + `) c`
 
 	var resp entity.TotalResponse
-	err := r.db.Get(&resp, query, args...)
+	err = db.Get(&resp, query, f.args...)
 	if err != nil {
 		log.Printf("db GetTotal query error: %s\n", err)
 		return nil, fmt.Errorf("db GetTotal query error: %w", err)
 	}
 
+	if req.GroupBy != "" {
+		resp.GroupBy = req.GroupBy
+		resp.Groups, err = totalGroups(db, req.GroupBy, charges, f.args)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	resp.UserID = req.UserID
 	resp.ServiceName = req.ServiceName
 
@@ -217,16 +432,3 @@ func (r *Repository) GetTotal(req *entity.TotalRequest) (*entity.TotalResponse,
 
 	return &resp, nil
 }
-
-func connectDB(dsn string, attempts int) (*sqlx.DB, error) {
-	for i := 1; i <= attempts; i++ {
-		db, err := sqlx.Connect("postgres", dsn)
-		if err == nil {
-			log.Printf("Connected to DB after %d attempts", i)
-			return db, nil
-		}
-		time.Sleep(time.Duration(i) * time.Second)
-	}
-
-	return nil, fmt.Errorf("unable to connect to DB")
-}
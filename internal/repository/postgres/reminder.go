@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"subscribe_service/internal/entity"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const reminderSchema = `
+	CREATE TABLE IF NOT EXISTS reminders_sent (
+		subscription_id UUID NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+		finish_date DATE NOT NULL,
+		sent_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+		PRIMARY KEY (subscription_id, finish_date)
+	);
+	`
+
+// reminderLockKey - ключ advisory lock, под которым работает рассылка напоминаний
+const reminderLockKey = 7_240_001
+
+// RunReminders под advisory lock находит подписки, последний день которых
+// (конец месяца finish_date) наступает в ближайшие daysBefore дней от today,
+// и передаёт их в notify. Окно, а не точная дата
+// today + daysBefore, нужно, чтобы пропущенный запуск не терял напоминания.
+// По подписке отправляется одно напоминание, пока не прошла дата окончания,
+// о которой уже напоминали: перенос окончания внутри окна не даёт повторного письма.
+// Успешно отправленные напоминания записываются, чтобы не отправлять их повторно,
+// неудачные будут повторены при следующем запуске.
+// Если блокировку держит другая реплика, возвращает locked = false
+func (r *Repository) RunReminders(today time.Time, daysBefore int, notify func(reminder *entity.Reminder) error) (sent int, locked bool, err error) {
+	var notifyErrs []error
+	err = r.withTx(func(tx *sqlx.Tx) error {
+		if err := tx.Get(&locked, `SELECT pg_try_advisory_xact_lock($1)`, reminderLockKey); err != nil {
+			return fmt.Errorf("advisory lock error: %w", err)
+		}
+		if !locked {
+			return nil
+		}
+
+		q := `SELECT ` + subscriptionColumns + `
+				FROM subscriptions s
+				WHERE ` + lastDaySQL("s.finish_date") + ` BETWEEN $1 AND $2
+					AND NOT EXISTS (
+						SELECT 1 FROM reminders_sent rs
+						WHERE rs.subscription_id = s.id AND ` + lastDaySQL("rs.finish_date") + ` >= $1
+					)
+				ORDER BY s.finish_date`
+
+		var subs []*entity.Subscription
+		if err := tx.Select(&subs, q, today, today.AddDate(0, 0, daysBefore)); err != nil {
+			return fmt.Errorf("db select reminders error: %w", err)
+		}
+
+		for _, sub := range subs {
+			lastDay, _ := sub.LastDay()
+			reminder := &entity.Reminder{
+				Subscription: sub,
+				FinishDate:   lastDay,
+				DaysLeft:     int(lastDay.Sub(today).Hours() / 24),
+			}
+
+			if err := notify(reminder); err != nil {
+				notifyErrs = append(notifyErrs, fmt.Errorf("notify subscription %s error: %w", sub.ID, err))
+				continue
+			}
+
+			q := `INSERT INTO reminders_sent (subscription_id, finish_date) VALUES ($1, $2)`
+			if _, err := tx.Exec(q, sub.ID, time.Time(*sub.FinishDate)); err != nil {
+				return fmt.Errorf("insert reminder error: %w", err)
+			}
+			sent++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, locked, err
+	}
+
+	return sent, locked, errors.Join(notifyErrs...)
+}
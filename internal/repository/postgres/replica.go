@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"strings"
+	"subscribe_service/internal/config"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// replicaCheckTimeout - сколько ждать ответа реплики при проверке
+const replicaCheckTimeout = 2 * time.Second
+
+type replica struct {
+	db      *sqlx.DB
+	healthy atomic.Bool
+}
+
+// replicaSet распределяет чтения по доступным репликам по кругу
+type replicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64
+}
+
+// openReplicas открывает подключения к репликам без проверки: реплика считается
+// недоступной до первой успешной проверки ReplicaChecker
+func openReplicas(cfg *config.Config) *replicaSet {
+	set := &replicaSet{}
+	for _, dsn := range cfg.DbReplicaDSNs {
+		if strings.TrimSpace(dsn) == "" {
+			continue
+		}
+		db, err := sqlx.Open("postgres", dsn)
+		if err != nil {
+			log.Println("opening replica error:", err)
+			continue
+		}
+		configurePool(db, cfg)
+		set.replicas = append(set.replicas, &replica{db: db})
+	}
+	return set
+}
+
+// pick возвращает следующую доступную реплику или primary, если доступных нет
+func (s *replicaSet) pick(primary *sqlx.DB) *sqlx.DB {
+	n := uint64(len(s.replicas))
+	start := s.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		if r := s.replicas[(start+i)%n]; r.healthy.Load() {
+			return r.db
+		}
+	}
+	return primary
+}
+
+// ReplicaChecker периодически проверяет реплики. Недоступная реплика исключается
+// из чтения до следующей успешной проверки, без доступных реплик чтение идёт
+// в основную базу
+type ReplicaChecker struct {
+	replicas *replicaSet
+	interval time.Duration
+}
+
+func NewReplicaChecker(cfg *config.Config, repo *Repository) *ReplicaChecker {
+	return &ReplicaChecker{
+		replicas: repo.replicas,
+		interval: cfg.DbReplicaCheckInterval,
+	}
+}
+
+// Run проверяет реплики сразу после запуска и затем раз в interval
+// до отмены контекста
+func (c *ReplicaChecker) Run(ctx context.Context) {
+	if c.replicas == nil || len(c.replicas.replicas) == 0 {
+		return
+	}
+	log.Println("Start replica checker...")
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Replica checker stopped")
+			return
+		case <-timer.C:
+		}
+
+		for i, r := range c.replicas.replicas {
+			checkCtx, cancel := context.WithTimeout(ctx, replicaCheckTimeout)
+			err := r.db.PingContext(checkCtx)
+			cancel()
+
+			healthy := err == nil
+			if r.healthy.Swap(healthy) != healthy {
+				if healthy {
+					log.Printf("replica %d is available\n", i)
+				} else {
+					log.Printf("replica %d is unavailable: %s\n", i, err)
+				}
+			}
+		}
+
+		timer.Reset(c.interval)
+	}
+}
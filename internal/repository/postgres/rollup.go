@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"subscribe_service/internal/entity"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// rollupSchema - помесячные начисления подписок, заранее развёрнутые monthlyChargesSQL.
+// Таблица хранит месяцы по subscription_charges_state.through включительно.
+// Пользователь и сервис берутся из subscriptions, поэтому начисления хранятся
+// по подписке: иначе нельзя посчитать число различных подписок за период
+const rollupSchema = `
+	CREATE TABLE IF NOT EXISTS subscription_charges (
+		subscription_id UUID NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+		month DATE NOT NULL,
+		charge INTEGER NOT NULL,
+
+		PRIMARY KEY (subscription_id, month)
+	);
+
+	CREATE INDEX IF NOT EXISTS subscription_charges_month_idx ON subscription_charges (month);
+
+	CREATE TABLE IF NOT EXISTS subscription_charges_state (
+		id BOOLEAN PRIMARY KEY DEFAULT true CHECK (id),
+		through DATE NOT NULL,
+		refreshed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+
+	CREATE INDEX IF NOT EXISTS subscriptions_user_idx ON subscriptions (user_id);
+	CREATE INDEX IF NOT EXISTS subscriptions_service_name_idx ON subscriptions (lower(service_name));
+`
+
+// rollupThrough возвращает последний месяц, за который заполнена subscription_charges.
+// ok равен false, если таблица ещё ни разу не заполнялась
+func rollupThrough(q sqlx.Queryer) (through time.Time, ok bool, err error) {
+	err = sqlx.Get(q, &through, `SELECT through FROM subscription_charges_state`)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("db reading rollup state error: %w", err)
+	}
+	return through, true, nil
+}
+
+// chargesSQL возвращает подзапрос с теми же колонками, что и monthlyChargesSQL.
+// Если период целиком входит в subscription_charges, начисления читаются из неё,
+// иначе разворачиваются из subscriptions
+func chargesSQL(db sqlx.Queryer, f *filter, from, to time.Time) (string, error) {
+	through, ok, err := rollupThrough(db)
+	if err != nil {
+		return "", err
+	}
+	if !ok || to.IsZero() || to.After(through) {
+		return monthlyChargesSQL(f, from, to), nil
+	}
+
+	where := f.where()
+	if where == "" {
+		where = " WHERE "
+	} else {
+		where += " AND "
+	}
+	where += "c.month BETWEEN date_trunc('month', " + f.arg(from) + "::date) AND date_trunc('month', " + f.arg(to) + "::date)"
+
+	return `SELECT s.id AS subscription_id, s.user_id, s.service_id, s.service_name, c.month, c.charge
+			FROM subscriptions s
+			JOIN subscription_charges c ON c.subscription_id = s.id` + where, nil
+}
+
+// GetMonthlyCharges возвращает начисления подписок ids за месяцы с from по to,
+// посчитанные так же, как итоги (см. chargesSQL). Месяцев паузы в результате нет
+func (r *Repository) GetMonthlyCharges(ids []uuid.UUID, from, to time.Time) ([]*entity.MonthlyCharge, error) {
+	db := r.reader()
+	f := &filter{}
+	f.add("s.id = ANY ($%d)", pq.Array(ids))
+	charges, err := chargesSQL(db, f, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	q := `SELECT subscription_id, month, charge FROM (` + charges + `) c`
+
+	resp := []*entity.MonthlyCharge{}
+	if err := sqlx.Select(db, &resp, q, f.args...); err != nil {
+		return nil, fmt.Errorf("db GetMonthlyCharges error: %w", err)
+	}
+
+	return resp, nil
+}
+
+// refreshChargesTx пересчитывает начисления подписки id в subscription_charges.
+// Начисления удалённой подписки удаляются каскадно, поэтому для неё ничего не вставляется
+func refreshChargesTx(tx sqlx.Ext, id uuid.UUID) error {
+	f := &filter{}
+	f.add("s.id = $%d", id)
+	return refreshCharges(tx, f, "subscription_id = $1")
+}
+
+// refreshCharges заменяет начисления подписок, подходящих под f, на пересчитанные
+// по subscription_charges_state.through. scope - условие удаления старых строк
+// с теми же аргументами, что и у f. Пока таблица не заполнена, ничего не делает
+func refreshCharges(tx sqlx.Ext, f *filter, scope string) error {
+	through, ok, err := rollupThrough(tx)
+	if err != nil || !ok {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM subscription_charges WHERE `+scope, f.args...); err != nil {
+		return fmt.Errorf("db clearing charges error: %w", err)
+	}
+
+	q := `INSERT INTO subscription_charges (subscription_id, month, charge)
+			SELECT subscription_id, month, charge FROM (` + monthlyChargesSQL(f, time.Time{}, through) + `) c`
+	if _, err := tx.Exec(q, f.args...); err != nil {
+		return fmt.Errorf("db refreshing charges error: %w", err)
+	}
+
+	return nil
+}
+
+// RefreshRollup перестраивает subscription_charges по месяц month включительно,
+// если она заполнена по другой месяц или force. Возвращает, была ли таблица перестроена
+func (r *Repository) RefreshRollup(month time.Time, force bool) (bool, error) {
+	refreshed := false
+	err := r.withTx(func(tx *sqlx.Tx) error {
+		// записи подписок ждут перестроения, иначе они пересчитают свои начисления
+		// по старой границе или потеряются, если таблица заполняется впервые
+		if _, err := tx.Exec(`LOCK TABLE subscriptions, subscription_pauses IN SHARE MODE`); err != nil {
+			return fmt.Errorf("db locking subscriptions error: %w", err)
+		}
+
+		through, ok, err := rollupThrough(tx)
+		if err != nil {
+			return err
+		}
+		if ok && through.Equal(month) && !force {
+			return nil
+		}
+
+		q := `INSERT INTO subscription_charges_state (through) VALUES ($1)
+				ON CONFLICT (id) DO UPDATE SET through = EXCLUDED.through, refreshed_at = now()`
+		if _, err := tx.Exec(q, month); err != nil {
+			return fmt.Errorf("db updating rollup state error: %w", err)
+		}
+
+		if err := refreshCharges(tx, &filter{}, "true"); err != nil {
+			return err
+		}
+
+		refreshed = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return refreshed, nil
+}
+
+// CheckRollup сравнивает subscription_charges с начислениями, развёрнутыми заново
+// из subscriptions, и возвращает расхождения. Пока таблица не заполнена, расхождений нет
+func (r *Repository) CheckRollup() (*entity.RollupCheck, error) {
+	through, ok, err := rollupThrough(r.db)
+	if err != nil {
+		return nil, err
+	}
+
+	check := &entity.RollupCheck{Mismatches: []*entity.RollupMismatch{}}
+	if !ok {
+		return check, nil
+	}
+	through = through.UTC()
+	check.Through = (*entity.CustomDate)(&through)
+
+	f := &filter{}
+	q := `SELECT COALESCE(c.subscription_id, e.subscription_id) AS subscription_id,
+				COALESCE(c.month, e.month) AS month,
+				c.charge AS stored, e.charge AS expected
+			FROM (SELECT * FROM subscription_charges WHERE month <= ` + f.arg(through) + `) c
+			FULL JOIN (` + monthlyChargesSQL(f, time.Time{}, through) + `) e
+				ON e.subscription_id = c.subscription_id AND e.month = c.month
+			WHERE c.charge IS DISTINCT FROM e.charge
+			ORDER BY 1, 2`
+
+	if err := r.db.Select(&check.Mismatches, q, f.args...); err != nil {
+		return nil, fmt.Errorf("db CheckRollup error: %w", err)
+	}
+
+	return check, nil
+}
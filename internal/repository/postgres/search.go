@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"subscribe_service/internal/entity"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// searchSchema включает pg_trgm и создаёт GIN-индексы для нечёткого
+// и полнотекстового поиска по названиям подписок и сервисов каталога.
+// services_search_text объявлена IMMUTABLE, чтобы по ней можно было построить индекс,
+// поэтому собирает псевдонимы через unnest и string_agg: array_to_string только STABLE
+const searchSchema = `
+	CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+	CREATE OR REPLACE FUNCTION services_search_text(name TEXT, aliases TEXT[]) RETURNS TEXT
+		LANGUAGE sql IMMUTABLE PARALLEL SAFE
+		AS $$ SELECT name || ' ' || COALESCE((SELECT string_agg(a, ' ') FROM unnest(aliases) a), '') $$;
+
+	CREATE INDEX IF NOT EXISTS subscriptions_service_name_trgm_idx
+		ON subscriptions USING GIN (service_name gin_trgm_ops);
+
+	CREATE INDEX IF NOT EXISTS subscriptions_service_name_fts_idx
+		ON subscriptions USING GIN (to_tsvector('simple', service_name));
+
+	CREATE INDEX IF NOT EXISTS services_search_trgm_idx
+		ON services USING GIN (services_search_text(name, aliases) gin_trgm_ops);
+
+	CREATE INDEX IF NOT EXISTS services_search_fts_idx
+		ON services USING GIN (to_tsvector('simple', services_search_text(name, aliases)));
+	`
+
+// Search ищет подписки по части названия сервиса с учётом опечаток. Подписка
+// находится по своему service_name или по названию и псевдонимам сервиса каталога.
+// Ранг - лучшее из сходства триграмм и полнотекстового ранга
+func (r *Repository) Search(req *entity.SearchRequest) (*entity.SearchResponse, error) {
+	resp := &entity.SearchResponse{
+		Query:   req.Query,
+		Limit:   req.Limit,
+		Offset:  req.Offset,
+		Results: []*entity.SearchResult{},
+	}
+
+	f := &filter{}
+	text := f.arg(req.Query)
+	tsq := f.arg(prefixTSQuery(req.Query))
+	pattern := f.arg("%" + escapeLike(req.Query) + "%")
+
+	// условия повторяют выражения индексов, чтобы планировщик мог их использовать
+	f.conditions = append(f.conditions, `(`+text+` <% s.service_name
+			OR s.service_name ILIKE `+pattern+`
+			OR to_tsvector('simple', s.service_name) @@ to_tsquery('simple', `+tsq+`)
+			OR s.service_id IN (
+				SELECT id FROM services
+				WHERE `+text+` <% services_search_text(name, aliases)
+					OR services_search_text(name, aliases) ILIKE `+pattern+`
+					OR to_tsvector('simple', services_search_text(name, aliases)) @@ to_tsquery('simple', `+tsq+`)
+			))`)
+	if req.UserID != uuid.Nil {
+		f.add("s.user_id = $%d", req.UserID)
+	}
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM subscriptions s` + f.where()
+	if err := r.db.Get(&total, countQuery, f.args...); err != nil {
+		return nil, fmt.Errorf("db Search count error: %w", err)
+	}
+	resp.Total = total
+
+	// название экранируется до подсветки, чтобы в ответ попадала только разметка <mark>
+	q := `SELECT m.*,
+				ts_headline('simple', ` + htmlEscapeSQL("m.service_name") + `, to_tsquery('simple', ` + tsq + `),
+					'StartSel=<mark>, StopSel=</mark>, HighlightAll=true') AS highlight
+			FROM (
+				SELECT ` + subscriptionColumns + `,
+					GREATEST(
+						word_similarity(` + text + `, s.service_name),
+						COALESCE((
+							SELECT word_similarity(` + text + `, services_search_text(c.name, c.aliases))
+							FROM services c WHERE c.id = s.service_id
+						), 0),
+						ts_rank(to_tsvector('simple', s.service_name), to_tsquery('simple', ` + tsq + `))
+					)::float8 AS rank
+				FROM subscriptions s` + f.where() + `
+				ORDER BY rank DESC, s.service_name, s.id
+				LIMIT ` + f.arg(req.Limit) + ` OFFSET ` + f.arg(req.Offset) + `
+			) m
+			ORDER BY m.rank DESC, m.service_name, m.id`
+
+	var rows []struct {
+		entity.Subscription
+		Rank      float64 `db:"rank"`
+		Highlight string  `db:"highlight"`
+	}
+	if err := r.db.Select(&rows, q, f.args...); err != nil {
+		return nil, fmt.Errorf("db Search error: %w", err)
+	}
+
+	subs := make([]*entity.Subscription, 0, len(rows))
+	for i := range rows {
+		row := &rows[i]
+		subs = append(subs, &row.Subscription)
+		resp.Results = append(resp.Results, &entity.SearchResult{
+			Subscription: &row.Subscription,
+			Rank:         row.Rank,
+			Highlight:    row.Highlight,
+		})
+	}
+
+	if err := attachRelations(r.db, subs...); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// prefixTSQuery строит запрос to_tsquery, в котором каждое слово ищется
+// как префикс: "yand pl" превращается в "yand:* & pl:*". Символы, кроме букв
+// и цифр, отбрасываются, поэтому пользовательский ввод не ломает синтаксис запроса
+func prefixTSQuery(s string) string {
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+	return strings.Join(words, " & ")
+}
+
+// htmlEscapeSQL возвращает SQL-выражение, экранирующее спецсимволы HTML в expr
+func htmlEscapeSQL(expr string) string {
+	return `replace(replace(replace(replace(replace(` + expr +
+		`, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;')`
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
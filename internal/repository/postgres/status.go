@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"subscribe_service/internal/entity"
+	"time"
+)
+
+// statusSQL возвращает выражение статуса подписки из table в месяце month.
+// Выражение повторяет entity.Subscription.StatusAt
+func statusSQL(table, month string) string {
+	m := "date_trunc('month', " + month + "::date)"
+	return `CASE
+				WHEN date_trunc('month', ` + table + `.start_date) > ` + m + ` THEN '` + entity.StatusScheduled + `'
+				WHEN date_trunc('month', ` + table + `.finish_date) < ` + m + ` THEN
+					CASE WHEN EXISTS (
+						SELECT 1 FROM subscription_cancellations sc WHERE sc.subscription_id = ` + table + `.id
+					) THEN '` + entity.StatusCancelled + `' ELSE '` + entity.StatusExpired + `' END
+				WHEN EXISTS (
+					SELECT 1 FROM subscription_pauses sp
+					WHERE sp.subscription_id = ` + table + `.id AND sp.start_date <= ` + m + `
+						AND (sp.end_date IS NULL OR sp.end_date >= ` + m + `)
+				) THEN '` + entity.StatusPaused + `'
+				WHEN date_trunc('month', ` + table + `.trial_end_date) > ` + m + ` THEN '` + entity.StatusTrialing + `'
+				ELSE '` + entity.StatusActive + `'
+			END`
+}
+
+// status оставляет подписки со статусом status в месяце asOf
+func (f *filter) status(status string, asOf *entity.CustomDate) {
+	if status == "" {
+		return
+	}
+
+	month := time.Now().UTC()
+	if asOf != nil && !time.Time(*asOf).IsZero() {
+		month = time.Time(*asOf)
+	}
+	f.add(statusSQL("subscriptions", f.arg(month))+" = $%d", status)
+}
+
+// setStatus вычисляет статус подписок на текущий месяц
+func setStatus(subs ...*entity.Subscription) {
+	now := time.Now().UTC()
+	for _, sub := range subs {
+		sub.Status = sub.StatusAt(now)
+	}
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"fmt"
+	"subscribe_service/internal/entity"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GetUserSummary считает сводку по подпискам пользователя за месяц month
+// и прогноз расходов на 12 месяцев вперёд. В TopServices попадают до top
+// сервисов с наибольшими расходами в месяце month
+func (r *Repository) GetUserSummary(userID uuid.UUID, month time.Time, top int) (*entity.UserSummary, error) {
+	resp := &entity.UserSummary{
+		UserID:      userID,
+		Month:       entity.CustomDate(month),
+		TopServices: []*entity.ServiceSpend{},
+	}
+
+	f := subscriptionFilter(userID, "", nil, nil)
+	charges, err := chargesSQL(r.db, f, month, month)
+	if err != nil {
+		return nil, err
+	}
+	q := `SELECT COUNT(DISTINCT subscription_id) AS active_count, COALESCE(SUM(charge), 0) AS monthly_spend
+			FROM (` + charges + `) c`
+
+	var current struct {
+		ActiveCount  int  `db:"active_count"`
+		MonthlySpend uint `db:"monthly_spend"`
+	}
+	if err := r.db.Get(&current, q, f.args...); err != nil {
+		return nil, fmt.Errorf("db GetUserSummary month error: %w", err)
+	}
+	resp.ActiveCount = current.ActiveCount
+	resp.MonthlySpend = current.MonthlySpend
+
+	f = subscriptionFilter(userID, "", nil, nil)
+	if charges, err = chargesSQL(r.db, f, month, month.AddDate(0, 11, 0)); err != nil {
+		return nil, err
+	}
+	q = `SELECT COALESCE(SUM(charge), 0) FROM (` + charges + `) c`
+	if err := r.db.Get(&resp.YearlyProjectedSpend, q, f.args...); err != nil {
+		return nil, fmt.Errorf("db GetUserSummary year error: %w", err)
+	}
+
+	f = subscriptionFilter(userID, "", nil, nil)
+	if charges, err = chargesSQL(r.db, f, month, month); err != nil {
+		return nil, err
+	}
+	q = `SELECT service_name, SUM(charge) AS monthly_spend, COUNT(*) AS count
+			FROM (` + charges + `) c
+			GROUP BY service_name
+			ORDER BY monthly_spend DESC, service_name
+			LIMIT ` + f.arg(top)
+	if err := r.db.Select(&resp.TopServices, q, f.args...); err != nil {
+		return nil, fmt.Errorf("db GetUserSummary top services error: %w", err)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"subscribe_service/internal/entity"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const tagSchema = `
+	CREATE TABLE IF NOT EXISTS tags (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		name VARCHAR(64) NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS tags_name_idx ON tags (lower(name));
+
+	CREATE TABLE IF NOT EXISTS subscription_tags (
+		subscription_id UUID NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+		tag_id UUID NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+		PRIMARY KEY (subscription_id, tag_id)
+	);
+
+	CREATE INDEX IF NOT EXISTS subscription_tags_tag_idx ON subscription_tags (tag_id);
+	`
+
+const tagColumns = `t.id, t.name, t.created_at,
+			(SELECT COUNT(*) FROM subscription_tags st WHERE st.tag_id = t.id) AS subscription_count`
+
+func (r *Repository) AddTag(name string) (*entity.Tag, error) {
+	q := `INSERT INTO tags (name) VALUES ($1)
+			RETURNING id, name, created_at, 0 AS subscription_count`
+
+	var tag entity.Tag
+	if err := r.db.Get(&tag, q, name); err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("tag %q already exists: %w", name, ErrConflict)
+		}
+		return nil, fmt.Errorf("db inserting tag error: %w", err)
+	}
+
+	return &tag, nil
+}
+
+func (r *Repository) ListTags() ([]*entity.Tag, error) {
+	q := `SELECT ` + tagColumns + ` FROM tags t ORDER BY lower(t.name)`
+
+	tags := []*entity.Tag{}
+	if err := r.db.Select(&tags, q); err != nil {
+		return nil, fmt.Errorf("db ListTags error: %w", err)
+	}
+
+	return tags, nil
+}
+
+// RenameTag переименовывает тег. Подписки ссылаются на тег по ID,
+// поэтому новое имя сразу видно во всех подписках
+func (r *Repository) RenameTag(id uuid.UUID, name string) (*entity.Tag, error) {
+	q := `WITH t AS (
+				UPDATE tags SET name = $2 WHERE id = $1
+				RETURNING id, name, created_at
+			)
+			SELECT ` + tagColumns + ` FROM t`
+
+	var tag entity.Tag
+	if err := r.db.Get(&tag, q, id, name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("tag id %s: %w", id, ErrIDNotFound)
+		}
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("tag %q already exists: %w", name, ErrConflict)
+		}
+		return nil, fmt.Errorf("db renaming tag error: %w", err)
+	}
+
+	return &tag, nil
+}
+
+// DeleteTag удаляет тег и снимает его со всех подписок
+func (r *Repository) DeleteTag(id uuid.UUID) error {
+	result, err := r.db.Exec(`DELETE FROM tags WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("db deleting tag error: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("db deleting tag error: %w", err)
+	}
+	if rows == 0 {
+		return ErrIDNotFound
+	}
+
+	return nil
+}
+
+// setTagsTx заменяет теги подписки на names, создавая недостающие теги.
+// Возвращает теги подписки в написании из справочника
+func setTagsTx(tx *sqlx.Tx, subscriptionID uuid.UUID, names []string) ([]string, error) {
+	lower := make([]string, 0, len(names))
+	for _, name := range names {
+		lower = append(lower, strings.ToLower(name))
+	}
+
+	q := `INSERT INTO tags (name) SELECT unnest($1::text[]) ON CONFLICT DO NOTHING`
+	if _, err := tx.Exec(q, pq.StringArray(names)); err != nil {
+		return nil, fmt.Errorf("db inserting tags error: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM subscription_tags WHERE subscription_id = $1`, subscriptionID); err != nil {
+		return nil, fmt.Errorf("db clearing subscription tags error: %w", err)
+	}
+
+	q = `INSERT INTO subscription_tags (subscription_id, tag_id)
+			SELECT $1, id FROM tags WHERE lower(name) = ANY ($2)`
+	if _, err := tx.Exec(q, subscriptionID, pq.StringArray(lower)); err != nil {
+		return nil, fmt.Errorf("db inserting subscription tags error: %w", err)
+	}
+
+	sub := &entity.Subscription{ID: subscriptionID}
+	if err := attachTags(tx, sub); err != nil {
+		return nil, err
+	}
+
+	return sub.Tags, nil
+}
+
+// attachTags загружает теги подписок одним запросом.
+// Подписки без тегов получают пустой список
+func attachTags(q sqlx.Queryer, subs ...*entity.Subscription) error {
+	if len(subs) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(subs))
+	byID := make(map[uuid.UUID][]*entity.Subscription, len(subs))
+	for _, sub := range subs {
+		sub.Tags = []string{}
+		if _, ok := byID[sub.ID]; !ok {
+			ids = append(ids, sub.ID.String())
+		}
+		byID[sub.ID] = append(byID[sub.ID], sub)
+	}
+
+	rows, err := q.Queryx(`SELECT st.subscription_id, t.name
+			FROM subscription_tags st
+			JOIN tags t ON t.id = st.tag_id
+			WHERE st.subscription_id = ANY ($1::uuid[])
+			ORDER BY lower(t.name)`, pq.StringArray(ids))
+	if err != nil {
+		return fmt.Errorf("db loading tags error: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uuid.UUID
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return fmt.Errorf("row scan error: %w", err)
+		}
+		for _, sub := range byID[id] {
+			sub.Tags = append(sub.Tags, name)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("db loading tags error: %w", err)
+	}
+
+	return nil
+}
+
+// totalGroups считает итоги помесячных списаний charges (см. monthlyChargesSQL)
+// по тегам или категориям
+func totalGroups(db sqlx.Queryer, groupBy, charges string, args []any) ([]*entity.TotalGroup, error) {
+	var join, key string
+	switch groupBy {
+	case entity.GroupByTag:
+		join = `LEFT JOIN subscription_tags st ON st.subscription_id = ch.subscription_id
+				LEFT JOIN tags t ON t.id = st.tag_id`
+		key = "COALESCE(t.name, '')"
+	case entity.GroupByCategory:
+		join = "LEFT JOIN services c ON c.id = ch.service_id"
+		key = "COALESCE(c.category, '')"
+	default:
+		return nil, fmt.Errorf("unknown group_by %q", groupBy)
+	}
+
+	q := `SELECT ` + key + ` AS key, COALESCE(SUM(ch.charge), 0) AS total, COUNT(DISTINCT ch.subscription_id) AS count
+			FROM (` + charges + `) ch
+			` + join + `
+			GROUP BY 1
+			ORDER BY total DESC, key`
+
+	groups := []*entity.TotalGroup{}
+	if err := sqlx.Select(db, &groups, q, args...); err != nil {
+		return nil, fmt.Errorf("db GetTotal groups error: %w", err)
+	}
+
+	return groups, nil
+}
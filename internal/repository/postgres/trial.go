@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"fmt"
+	"subscribe_service/internal/entity"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// trialSchema добавляет подпискам пробный период. trial_converted_at
+// отмечает подписки, по которым уже отправлено событие конверсии
+const trialSchema = `
+	ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS trial_end_date DATE;
+	ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS trial_converted_at TIMESTAMPTZ;
+
+	DO $$ BEGIN
+		ALTER TABLE subscriptions ADD CONSTRAINT valid_trial_range
+			CHECK (trial_end_date IS NULL OR trial_end_date >= start_date);
+	EXCEPTION WHEN duplicate_object THEN NULL;
+	END $$;
+
+	CREATE INDEX IF NOT EXISTS subscriptions_trial_pending_idx
+		ON subscriptions (trial_end_date) WHERE trial_converted_at IS NULL;
+	`
+
+// ListTrialsEnding возвращает подписки, пробный период которых заканчивается
+// в окне [From, To] и которые не отменены до его окончания
+func (r *Repository) ListTrialsEnding(req *entity.TrialsRequest) ([]*entity.Subscription, error) {
+	q := `SELECT ` + subscriptionColumns + `
+			FROM subscriptions`
+
+	f := subscriptionFilter(req.UserID, "", nil, nil)
+	f.add("trial_end_date >= $%d", req.From)
+	f.add("trial_end_date <= $%d", req.To)
+	f.conditions = append(f.conditions, "(finish_date IS NULL OR finish_date >= trial_end_date)")
+	q += f.where() + " ORDER BY trial_end_date, id"
+
+	var resp []*entity.Subscription
+	if err := r.db.Select(&resp, q, f.args...); err != nil {
+		return nil, fmt.Errorf("db ListTrialsEnding error: %w", err)
+	}
+
+	if err := attachRelations(r.db, resp...); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// ConvertTrials отмечает подписки, пробный период которых закончился не позже today
+// и которые не были отменены до его окончания, и пишет для каждой событие конверсии
+// в outbox. Повторный вызов не отправляет событие второй раз, а параллельные вызовы
+// не пересекаются, потому что UPDATE блокирует строки
+func (r *Repository) ConvertTrials(today time.Time) (int, error) {
+	var converted []*entity.Subscription
+	err := r.withTx(func(tx *sqlx.Tx) error {
+		q := `UPDATE subscriptions
+				SET trial_converted_at = now()
+				WHERE trial_converted_at IS NULL
+					AND trial_end_date <= $1
+					AND (finish_date IS NULL OR finish_date >= trial_end_date)
+				RETURNING ` + subscriptionColumns
+
+		converted = nil
+		if err := tx.Select(&converted, q, today); err != nil {
+			return fmt.Errorf("db converting trials error: %w", err)
+		}
+
+		if err := attachRelations(tx, converted...); err != nil {
+			return err
+		}
+
+		for _, sub := range converted {
+			if err := insertHistory(tx, entity.EventSubscriptionTrialConverted, sub); err != nil {
+				return err
+			}
+			if err := insertOutbox(tx, entity.EventSubscriptionTrialConverted, sub); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(converted), nil
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"fmt"
+	"subscribe_service/internal/entity"
+)
+
+// ListUpcoming возвращает подписки, последний день которых попадает в окно [From, To],
+// и бессрочные подписки, начавшиеся не позже To
+func (r *Repository) ListUpcoming(req *entity.UpcomingRequest) ([]*entity.Subscription, error) {
+	q := `SELECT ` + subscriptionColumns + `
+			FROM subscriptions`
+
+	f := subscriptionFilter(req.UserID, req.ServiceName, nil, nil)
+	f.add("start_date <= $%d", req.To)
+	f.add("(finish_date IS NULL OR "+lastDaySQL("finish_date")+" >= $%d)", req.From)
+	f.add("(finish_date IS NULL OR "+lastDaySQL("finish_date")+" <= $%d)", req.To)
+	q += f.where() + " ORDER BY start_date"
+
+	var resp []*entity.Subscription
+	if err := r.db.Select(&resp, q, f.args...); err != nil {
+		return nil, fmt.Errorf("db ListUpcoming error: %w", err)
+	}
+
+	if err := attachRelations(r.db, resp...); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// lastDaySQL возвращает SQL-выражение последнего дня месяца окончания finishDate,
+// как entity.Subscription.LastDay: подписка действует до конца этого месяца
+func lastDaySQL(finishDate string) string {
+	return "(date_trunc('month', " + finishDate + ") + interval '1 month - 1 day')::date"
+}
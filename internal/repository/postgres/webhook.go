@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"subscribe_service/internal/entity"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const webhookSchema = `
+	CREATE TABLE IF NOT EXISTS webhook_endpoints (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		url TEXT NOT NULL,
+		secret TEXT NOT NULL,
+		events TEXT[] NOT NULL DEFAULT '{}',
+		active BOOLEAN NOT NULL DEFAULT TRUE,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+
+	CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		endpoint_id UUID NOT NULL REFERENCES webhook_endpoints (id) ON DELETE CASCADE,
+		event_id UUID NOT NULL,
+		event_type TEXT NOT NULL,
+		payload JSONB NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		last_error TEXT,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		delivered_at TIMESTAMPTZ
+	);
+
+	CREATE INDEX IF NOT EXISTS webhook_deliveries_pending_idx
+		ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+
+	CREATE UNIQUE INDEX IF NOT EXISTS webhook_deliveries_event_idx
+		ON webhook_deliveries (endpoint_id, event_id);
+	`
+
+// webhookEndpointRow нужен для чтения массива событий из TEXT[]
+type webhookEndpointRow struct {
+	entity.WebhookEndpoint
+	Events pq.StringArray `db:"events"`
+}
+
+func (row *webhookEndpointRow) toEntity() *entity.WebhookEndpoint {
+	endpoint := row.WebhookEndpoint
+	endpoint.Events = []string(row.Events)
+	return &endpoint
+}
+
+const webhookDeliveryColumns = `d.id, d.endpoint_id, e.url, e.secret, d.event_id, d.event_type, d.payload,
+			d.status, d.attempts, d.next_attempt_at, d.last_error, d.created_at, d.delivered_at`
+
+func (r *Repository) AddWebhookEndpoint(req *entity.CreateWebhookRequest) (*entity.WebhookEndpoint, error) {
+	q := `INSERT INTO webhook_endpoints (url, secret, events)
+			VALUES ($1, $2, $3)
+			RETURNING id, url, secret, events, active, created_at`
+
+	var row webhookEndpointRow
+	err := r.db.Get(&row, q, req.URL, req.Secret, pq.StringArray(req.Events))
+	if err != nil {
+		return nil, fmt.Errorf("db inserting webhook endpoint error: %w", err)
+	}
+
+	return row.toEntity(), nil
+}
+
+func (r *Repository) ListWebhookEndpoints() ([]*entity.WebhookEndpoint, error) {
+	q := `SELECT id, url, secret, events, active, created_at
+			FROM webhook_endpoints
+			ORDER BY created_at`
+
+	var rows []webhookEndpointRow
+	if err := r.db.Select(&rows, q); err != nil {
+		return nil, fmt.Errorf("db ListWebhookEndpoints error: %w", err)
+	}
+
+	resp := make([]*entity.WebhookEndpoint, 0, len(rows))
+	for i := range rows {
+		resp = append(resp, rows[i].toEntity())
+	}
+
+	return resp, nil
+}
+
+func (r *Repository) DeleteWebhookEndpoint(id uuid.UUID) error {
+	result, err := r.db.Exec(`DELETE FROM webhook_endpoints WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete webhook endpoint %s error: %w", id, err)
+	}
+
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("delete webhook endpoint %s error: %w", id, err)
+	} else if rowsAffected == 0 {
+		return ErrIDNotFound
+	}
+
+	return nil
+}
+
+// EnqueueEvent ставит событие в очередь доставки для каждого активного
+// вебхука, подписанного на его тип. Пустой список событий означает все события.
+// Повторная постановка того же события игнорируется
+func (r *Repository) EnqueueEvent(event *entity.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshalling event %s error: %w", event.ID, err)
+	}
+
+	q := `INSERT INTO webhook_deliveries (endpoint_id, event_id, event_type, payload)
+			SELECT id, $1, $2, $3
+			FROM webhook_endpoints
+			WHERE active AND (cardinality(events) = 0 OR $2 = ANY(events))
+			ON CONFLICT (endpoint_id, event_id) DO NOTHING`
+
+	if _, err := r.db.Exec(q, event.ID, event.Type, payload); err != nil {
+		return fmt.Errorf("enqueue event %s error: %w", event.ID, err)
+	}
+
+	return nil
+}
+
+// ClaimWebhookDeliveries забирает до limit доставок, время которых подошло,
+// и откладывает их следующую попытку на lease. Параллельные обработчики
+// не получат одни и те же доставки благодаря SKIP LOCKED
+func (r *Repository) ClaimWebhookDeliveries(limit int, lease time.Duration) ([]*entity.WebhookDelivery, error) {
+	q := `WITH claimed AS (
+				UPDATE webhook_deliveries
+				SET next_attempt_at = now() + make_interval(secs => $2)
+				WHERE id IN (
+					SELECT id FROM webhook_deliveries
+					WHERE status = 'pending' AND next_attempt_at <= now()
+					ORDER BY next_attempt_at
+					LIMIT $1
+					FOR UPDATE SKIP LOCKED
+				)
+				RETURNING *
+			)
+			SELECT ` + webhookDeliveryColumns + `
+			FROM claimed d
+			JOIN webhook_endpoints e ON e.id = d.endpoint_id
+			ORDER BY d.created_at`
+
+	var resp []*entity.WebhookDelivery
+	if err := r.db.Select(&resp, q, limit, lease.Seconds()); err != nil {
+		return nil, fmt.Errorf("db ClaimWebhookDeliveries error: %w", err)
+	}
+
+	return resp, nil
+}
+
+func (r *Repository) MarkWebhookDelivered(id uuid.UUID) error {
+	q := `UPDATE webhook_deliveries
+			SET status = 'delivered', attempts = attempts + 1, delivered_at = now(), last_error = NULL
+			WHERE id = $1`
+
+	if _, err := r.db.Exec(q, id); err != nil {
+		return fmt.Errorf("mark webhook delivery %s delivered error: %w", id, err)
+	}
+
+	return nil
+}
+
+// MarkWebhookFailed фиксирует неудачную попытку. Если dead, доставка
+// больше не повторяется, иначе следующая попытка будет в nextAttemptAt
+func (r *Repository) MarkWebhookFailed(id uuid.UUID, lastError string, nextAttemptAt time.Time, dead bool) error {
+	status := entity.DeliveryPending
+	if dead {
+		status = entity.DeliveryDead
+	}
+
+	q := `UPDATE webhook_deliveries
+			SET status = $2, attempts = attempts + 1, next_attempt_at = $3, last_error = $4
+			WHERE id = $1`
+
+	if _, err := r.db.Exec(q, id, status, nextAttemptAt, lastError); err != nil {
+		return fmt.Errorf("mark webhook delivery %s failed error: %w", id, err)
+	}
+
+	return nil
+}
+
+// ListWebhookDeliveries возвращает страницу доставок с указанным статусом, начиная
+// с последних, и общее число таких доставок. Пустой статус возвращает все доставки
+func (r *Repository) ListWebhookDeliveries(req *entity.WebhookDeliveriesRequest) (*entity.WebhookDeliveriesResponse, error) {
+	resp := &entity.WebhookDeliveriesResponse{
+		Status:     req.Status,
+		Limit:      req.Limit,
+		Offset:     req.Offset,
+		Deliveries: []*entity.WebhookDelivery{},
+	}
+
+	f := &filter{}
+	if req.Status != "" {
+		f.add("d.status = $%d", req.Status)
+	}
+
+	countQuery := `SELECT COUNT(*) FROM webhook_deliveries d` + f.where()
+	if err := r.db.Get(&resp.Total, countQuery, f.args...); err != nil {
+		return nil, fmt.Errorf("db ListWebhookDeliveries count error: %w", err)
+	}
+
+	q := `SELECT ` + webhookDeliveryColumns + `
+			FROM webhook_deliveries d
+			JOIN webhook_endpoints e ON e.id = d.endpoint_id` + f.where() + `
+			ORDER BY d.created_at DESC, d.id
+			LIMIT ` + f.arg(req.Limit) + ` OFFSET ` + f.arg(req.Offset)
+
+	if err := r.db.Select(&resp.Deliveries, q, f.args...); err != nil {
+		return nil, fmt.Errorf("db ListWebhookDeliveries error: %w", err)
+	}
+
+	return resp, nil
+}
+
+// RedeliverWebhook возвращает недоставленное событие в очередь
+func (r *Repository) RedeliverWebhook(id uuid.UUID) error {
+	q := `UPDATE webhook_deliveries
+			SET status = 'pending', attempts = 0, next_attempt_at = now(), last_error = NULL
+			WHERE id = $1 AND status = 'dead'`
+
+	result, err := r.db.Exec(q, id)
+	if err != nil {
+		return fmt.Errorf("redeliver webhook %s error: %w", id, err)
+	}
+
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("redeliver webhook %s error: %w", id, err)
+	} else if rowsAffected == 0 {
+		return ErrIDNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package rollup
+
+import (
+	"context"
+	"log"
+	"subscribe_service/internal/config"
+	"time"
+)
+
+type Store interface {
+	RefreshRollup(month time.Time, force bool) (bool, error)
+}
+
+// Refresher периодически продлевает помесячные начисления по текущий месяц.
+// Внутри месяца начисления обновляются при записи подписок, поэтому
+// перестроение выполняется только при смене месяца
+type Refresher struct {
+	store    Store
+	interval time.Duration
+}
+
+func NewRefresher(cfg *config.Config, store Store) *Refresher {
+	return &Refresher{
+		store:    store,
+		interval: cfg.RollupRefreshInterval,
+	}
+}
+
+// Run проверяет начисления сразу после запуска и затем раз в interval
+// до отмены контекста
+func (r *Refresher) Run(ctx context.Context) {
+	log.Println("Start rollup refresher...")
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Rollup refresher stopped")
+			return
+		case <-timer.C:
+		}
+
+		now := time.Now().UTC()
+		month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		refreshed, err := r.store.RefreshRollup(month, false)
+		if err != nil {
+			log.Println("refreshing rollup error:", err)
+		} else if refreshed {
+			log.Printf("rollup refreshed through %s\n", month.Format("01-2006"))
+		}
+
+		timer.Reset(r.interval)
+	}
+}
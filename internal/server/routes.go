@@ -13,7 +13,63 @@ func setupRouter(r chi.Router, c Controller) {
 		r.Delete("/api/v1/subscription/{id}", c.Delete)
 		r.Get("/api/v1/subscription", c.List)
 		r.Get("/api/v1/subscription/total", c.GetTotal)
+		r.Get("/api/v1/subscription/forecast", c.GetForecast)
+		r.Get("/api/v1/subscription/upcoming", c.GetUpcoming)
+		r.Post("/api/v1/subscription/batch", c.Batch)
+		r.Post("/api/v1/subscription/import", c.Import)
+		r.Get("/api/v1/subscription/export", c.Export)
+		r.Get("/api/v1/subscription/search", c.Search)
+		r.Get("/api/v1/subscription/trials", c.GetTrialsEnding)
+		r.Post("/api/v1/subscription/{id}/pause", c.Pause)
+		r.Post("/api/v1/subscription/{id}/resume", c.Resume)
+		r.Post("/api/v1/subscription/{id}/price-changes", c.SchedulePriceChange)
+		r.Delete("/api/v1/subscription/{id}/price-changes/{month}", c.CancelPriceChange)
+		r.Post("/api/v1/subscription/{id}/cancel", c.Cancel)
+		r.Get("/api/v1/subscription/churn", c.GetChurn)
 	})
 
+	r.Group(func(r chi.Router) {
+		r.Get("/api/v1/analytics/mrr", c.GetMRR)
+		r.Get("/api/v1/analytics/churn", c.GetChurnRate)
+		r.Get("/api/v1/analytics/cohorts", c.GetCohorts)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Get("/api/v1/users/{user_id}/subscriptions", c.ListUserSubscriptions)
+		r.Post("/api/v1/users/{user_id}/subscriptions", c.CreateUserSubscription)
+		r.Get("/api/v1/users/{user_id}/summary", c.GetUserSummary)
+		r.Get("/api/v1/users/{user_id}/subscriptions.ics", c.Calendar)
+		r.Post("/api/v1/users/{user_id}/budgets", c.CreateBudget)
+		r.Get("/api/v1/users/{user_id}/budgets", c.ListBudgets)
+		r.Put("/api/v1/users/{user_id}/budgets/{id}", c.UpdateBudget)
+		r.Delete("/api/v1/users/{user_id}/budgets/{id}", c.DeleteBudget)
+		r.Get("/api/v1/users/{user_id}/budget-status", c.GetBudgetStatus)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Post("/api/v1/webhooks", c.CreateWebhook)
+		r.Get("/api/v1/webhooks", c.ListWebhooks)
+		r.Delete("/api/v1/webhooks/{id}", c.DeleteWebhook)
+		r.Get("/api/v1/webhooks/deliveries", c.ListWebhookDeliveries)
+		r.Post("/api/v1/webhooks/deliveries/{id}/redeliver", c.RedeliverWebhook)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Post("/api/v1/services", c.CreateCatalogService)
+		r.Get("/api/v1/services", c.ListCatalogServices)
+		r.Get("/api/v1/services/{id}", c.GetCatalogService)
+		r.Put("/api/v1/services/{id}", c.UpdateCatalogService)
+		r.Delete("/api/v1/services/{id}", c.DeleteCatalogService)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Post("/api/v1/tags", c.CreateTag)
+		r.Get("/api/v1/tags", c.ListTags)
+		r.Put("/api/v1/tags/{id}", c.RenameTag)
+		r.Delete("/api/v1/tags/{id}", c.DeleteTag)
+	})
+
+	r.Get("/api/v1/cache/stats", c.GetCacheStats)
+
 	r.Get("/swagger/*", c.Swagger)
 }
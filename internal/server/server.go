@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/signal"
 	"subscribe_service/internal/config"
+	"subscribe_service/internal/consistency"
 	"syscall"
 	"time"
 
@@ -21,6 +22,53 @@ type Controller interface {
 	List(w http.ResponseWriter, r *http.Request)
 
 	GetTotal(w http.ResponseWriter, r *http.Request)
+	GetForecast(w http.ResponseWriter, r *http.Request)
+	GetUpcoming(w http.ResponseWriter, r *http.Request)
+	Batch(w http.ResponseWriter, r *http.Request)
+	Import(w http.ResponseWriter, r *http.Request)
+	Export(w http.ResponseWriter, r *http.Request)
+	Search(w http.ResponseWriter, r *http.Request)
+	GetTrialsEnding(w http.ResponseWriter, r *http.Request)
+	Pause(w http.ResponseWriter, r *http.Request)
+	Resume(w http.ResponseWriter, r *http.Request)
+	SchedulePriceChange(w http.ResponseWriter, r *http.Request)
+	CancelPriceChange(w http.ResponseWriter, r *http.Request)
+	Cancel(w http.ResponseWriter, r *http.Request)
+	GetChurn(w http.ResponseWriter, r *http.Request)
+
+	GetMRR(w http.ResponseWriter, r *http.Request)
+	GetChurnRate(w http.ResponseWriter, r *http.Request)
+	GetCohorts(w http.ResponseWriter, r *http.Request)
+
+	ListUserSubscriptions(w http.ResponseWriter, r *http.Request)
+	CreateUserSubscription(w http.ResponseWriter, r *http.Request)
+	GetUserSummary(w http.ResponseWriter, r *http.Request)
+	Calendar(w http.ResponseWriter, r *http.Request)
+
+	CreateWebhook(w http.ResponseWriter, r *http.Request)
+	ListWebhooks(w http.ResponseWriter, r *http.Request)
+	DeleteWebhook(w http.ResponseWriter, r *http.Request)
+	ListWebhookDeliveries(w http.ResponseWriter, r *http.Request)
+	RedeliverWebhook(w http.ResponseWriter, r *http.Request)
+
+	CreateCatalogService(w http.ResponseWriter, r *http.Request)
+	GetCatalogService(w http.ResponseWriter, r *http.Request)
+	ListCatalogServices(w http.ResponseWriter, r *http.Request)
+	UpdateCatalogService(w http.ResponseWriter, r *http.Request)
+	DeleteCatalogService(w http.ResponseWriter, r *http.Request)
+
+	CreateTag(w http.ResponseWriter, r *http.Request)
+	ListTags(w http.ResponseWriter, r *http.Request)
+	RenameTag(w http.ResponseWriter, r *http.Request)
+	DeleteTag(w http.ResponseWriter, r *http.Request)
+
+	CreateBudget(w http.ResponseWriter, r *http.Request)
+	ListBudgets(w http.ResponseWriter, r *http.Request)
+	UpdateBudget(w http.ResponseWriter, r *http.Request)
+	DeleteBudget(w http.ResponseWriter, r *http.Request)
+	GetBudgetStatus(w http.ResponseWriter, r *http.Request)
+
+	GetCacheStats(w http.ResponseWriter, r *http.Request)
 
 	Swagger(w http.ResponseWriter, r *http.Request)
 }
@@ -32,6 +80,9 @@ type Server struct {
 
 func NewServer(cfg *config.Config, c Controller) *Server {
 	r := chi.NewRouter()
+	if cfg.ReadYourWritesWindow > 0 {
+		r.Use(consistency.NewTracker(cfg.ReadYourWritesWindow).Middleware)
+	}
 	setupRouter(r, c)
 
 	s := &Server{
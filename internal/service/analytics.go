@@ -0,0 +1,91 @@
+package service
+
+import (
+	"fmt"
+	"subscribe_service/internal/entity"
+	"time"
+)
+
+const (
+	defaultAnalyticsMonths = 12
+	maxAnalyticsMonths     = 120
+)
+
+// GetMRR возвращает ряд MRR за период, по умолчанию за последние 12 месяцев
+func (s *service) GetMRR(req *entity.AnalyticsRequest) (*entity.MRRResponse, error) {
+	if err := analyticsPeriod(req); err != nil {
+		return nil, err
+	}
+
+	items, err := s.reads.GetMRR(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.MRRResponse{Items: items}, nil
+}
+
+// GetChurnRate возвращает помесячный отток за период, по умолчанию за последние 12 месяцев
+func (s *service) GetChurnRate(req *entity.AnalyticsRequest) (*entity.ChurnRateResponse, error) {
+	if err := analyticsPeriod(req); err != nil {
+		return nil, err
+	}
+
+	items, err := s.reads.GetChurnRate(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.ChurnRateResponse{Items: items}, nil
+}
+
+// GetCohorts возвращает матрицу удержания когорт, начавшихся в периоде,
+// по умолчанию за последние 12 месяцев. Удержание считается по текущий месяц
+// или по finish_date запроса
+func (s *service) GetCohorts(req *entity.AnalyticsRequest) (*entity.CohortResponse, error) {
+	if err := analyticsPeriod(req); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.reads.GetCohorts(req)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		row.Rates = make([]float64, len(row.Retained))
+		for i, retained := range row.Retained {
+			if row.Size > 0 {
+				row.Rates[i] = float64(retained) / float64(row.Size)
+			}
+		}
+	}
+
+	return &entity.CohortResponse{Cohorts: rows}, nil
+}
+
+// analyticsPeriod заполняет период по умолчанию: последние 12 месяцев по текущий
+// включительно. Даты приводятся к первому числу месяца, период ограничен
+// maxAnalyticsMonths месяцами
+func analyticsPeriod(req *entity.AnalyticsRequest) error {
+	finish := currentMonth()
+	if req.FinishDate != nil && !time.Time(*req.FinishDate).IsZero() {
+		finish = monthStart(time.Time(*req.FinishDate))
+	}
+
+	start := finish.AddDate(0, 1-defaultAnalyticsMonths, 0)
+	if req.StartDate != nil && !time.Time(*req.StartDate).IsZero() {
+		start = monthStart(time.Time(*req.StartDate))
+	}
+
+	if start.After(finish) {
+		return fmt.Errorf("%w: start_date is after finish_date", ErrInvalidRequest)
+	}
+	if months := (finish.Year()-start.Year())*12 + int(finish.Month()-start.Month()) + 1; months > maxAnalyticsMonths {
+		return fmt.Errorf("%w: period must not exceed %d months", ErrInvalidRequest, maxAnalyticsMonths)
+	}
+
+	startDate, finishDate := entity.CustomDate(start), entity.CustomDate(finish)
+	req.StartDate, req.FinishDate = &startDate, &finishDate
+	return nil
+}
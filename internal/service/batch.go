@@ -0,0 +1,110 @@
+package service
+
+import (
+	"fmt"
+	"subscribe_service/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+// maxBatchSize ограничивает число операций в пакете, в том числе чтобы
+// многострочный INSERT не превысил лимит параметров Postgres
+const maxBatchSize = 1000
+
+// Batch проверяет операции и выполняет корректные одной транзакцией.
+// В атомарном режиме любая некорректная операция отменяет весь пакет
+func (s *service) Batch(req *entity.BatchRequest) (*entity.BatchResponse, error) {
+	if req.Mode == "" {
+		req.Mode = entity.BatchAtomic
+	}
+	if req.Mode != entity.BatchAtomic && req.Mode != entity.BatchBestEffort {
+		return nil, fmt.Errorf("%w: unknown batch mode %q", ErrInvalidRequest, req.Mode)
+	}
+	if len(req.Operations) == 0 {
+		return nil, fmt.Errorf("%w: operations are required", ErrInvalidRequest)
+	}
+	if len(req.Operations) > maxBatchSize {
+		return nil, fmt.Errorf("%w: batch is larger than %d operations", ErrInvalidRequest, maxBatchSize)
+	}
+
+	atomic := req.Mode == entity.BatchAtomic
+	results := make([]*entity.BatchItemResult, len(req.Operations))
+	valid := make([]*entity.BatchOperation, 0, len(req.Operations))
+	validIdx := make([]int, 0, len(req.Operations))
+	resolver := s.newServiceResolver()
+	for i, op := range req.Operations {
+		err := validateBatchOperation(op)
+		if err == nil && op.Subscription != nil {
+			op.Subscription.Tags, err = normalizeTags(op.Subscription.Tags)
+		}
+		if err == nil && op.Subscription != nil {
+			err = validateTrial(op.Subscription.StartDate, &op.Subscription.TrialEndDate)
+		}
+		if err == nil && op.Subscription != nil {
+			err = resolver.resolveSubscription(op.Subscription)
+		}
+		if err != nil {
+			results[i] = &entity.BatchItemResult{Index: i, Op: op.Op, Status: entity.BatchStatusFailed, Error: err.Error()}
+			continue
+		}
+		valid = append(valid, op)
+		validIdx = append(validIdx, i)
+	}
+
+	if atomic && len(valid) < len(req.Operations) {
+		for i, op := range req.Operations {
+			if results[i] == nil {
+				results[i] = &entity.BatchItemResult{Index: i, Op: op.Op, Status: entity.BatchStatusRolledBack}
+			}
+		}
+		return newBatchResponse(req.Mode, results), nil
+	}
+
+	if len(valid) > 0 {
+		applied, err := s.repo.Batch(valid, atomic, prepareUpdate, s.checkBudgets)
+		if err != nil {
+			return nil, err
+		}
+		for k, result := range applied {
+			result.Index = validIdx[k]
+			results[result.Index] = result
+		}
+	}
+
+	return newBatchResponse(req.Mode, results), nil
+}
+
+func validateBatchOperation(op *entity.BatchOperation) error {
+	switch op.Op {
+	case entity.BatchCreate:
+		if op.Subscription == nil {
+			return fmt.Errorf("%w: subscription is required", ErrInvalidRequest)
+		}
+		return validateSubscription(op.Subscription, false)
+	case entity.BatchUpdate:
+		if op.Subscription == nil {
+			return fmt.Errorf("%w: subscription is required", ErrInvalidRequest)
+		}
+		return validateSubscription(op.Subscription, true)
+	case entity.BatchDelete:
+		if op.ID == uuid.Nil {
+			return fmt.Errorf("%w: id is required", ErrInvalidRequest)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("%w: unknown operation %q", ErrInvalidRequest, op.Op)
+}
+
+func newBatchResponse(mode string, results []*entity.BatchItemResult) *entity.BatchResponse {
+	resp := &entity.BatchResponse{Mode: mode, Results: results}
+	for _, result := range results {
+		switch result.Status {
+		case entity.BatchStatusOK:
+			resp.Succeeded++
+		case entity.BatchStatusFailed:
+			resp.Failed++
+		}
+	}
+	return resp
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"subscribe_service/internal/entity"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrBudgetExceeded означает, что подписка выводит расходы пользователя за бюджет
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+func (s *service) CreateBudget(userID uuid.UUID, req *entity.BudgetRequest) (*entity.Budget, error) {
+	budget, err := s.newBudget(userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.AddBudget(budget)
+}
+
+func (s *service) ListBudgets(userID uuid.UUID) (*entity.BudgetsResponse, error) {
+	budgets, err := s.repo.ListBudgets(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.BudgetsResponse{Budgets: budgets}, nil
+}
+
+func (s *service) UpdateBudget(userID, id uuid.UUID, req *entity.BudgetRequest) (*entity.Budget, error) {
+	budget, err := s.newBudget(userID, req)
+	if err != nil {
+		return nil, err
+	}
+	budget.ID = id
+
+	return s.repo.UpdateBudget(budget)
+}
+
+func (s *service) DeleteBudget(userID, id uuid.UUID) error {
+	return s.repo.DeleteBudget(userID, id)
+}
+
+// GetBudgetStatus сравнивает бюджеты пользователя с его расходами за месяц month,
+// по умолчанию за текущий
+func (s *service) GetBudgetStatus(userID uuid.UUID, month *entity.CustomDate) (*entity.BudgetStatusResponse, error) {
+	m := currentMonth()
+	if month != nil && !time.Time(*month).IsZero() {
+		m = monthStart(time.Time(*month))
+	}
+
+	statuses, err := s.repo.GetBudgetStatus(userID, m)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.BudgetStatusResponse{UserID: userID, Month: entity.CustomDate(m), Budgets: statuses}, nil
+}
+
+// newBudget проверяет запрос и находит сервис бюджета в каталоге. Неизвестные
+// сервисы в каталог не добавляются
+func (s *service) newBudget(userID uuid.UUID, req *entity.BudgetRequest) (*entity.Budget, error) {
+	budget := &entity.Budget{
+		UserID:   userID,
+		Category: strings.TrimSpace(req.Category),
+		Amount:   req.Amount,
+	}
+	if len(budget.Category) > 64 {
+		return nil, fmt.Errorf("%w: category is longer than 64 bytes", ErrInvalidRequest)
+	}
+
+	serviceName := strings.TrimSpace(req.ServiceName)
+	if req.ServiceID == uuid.Nil && serviceName == "" {
+		return budget, nil
+	}
+	if budget.Category != "" {
+		return nil, fmt.Errorf("%w: budget is either for a category or for a service", ErrInvalidRequest)
+	}
+
+	var svc *entity.CatalogService
+	var err error
+	if req.ServiceID != uuid.Nil {
+		svc, err = s.repo.GetCatalogService(req.ServiceID)
+	} else {
+		svc, err = s.repo.FindCatalogService(serviceName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if svc == nil {
+		return nil, fmt.Errorf("%w: unknown service %q", ErrInvalidRequest, serviceName)
+	}
+	budget.ServiceID = svc.ID
+	budget.ServiceName = svc.Name
+
+	return budget, nil
+}
+
+// checkBudgets решает, можно ли сохранить подписку sub, которая выводит за лимит
+// бюджеты exceeded. Вызывается репозиторием в транзакции записи, поэтому расходы
+// учитывают параллельные изменения. При BUDGET_ENFORCE без allow_over_budget
+// возвращает ErrBudgetExceeded, и запись отменяется. sub равна nil при импорте,
+// для которого allow_over_budget не задаётся
+func (s *service) checkBudgets(sub *entity.Subscription, exceeded []*entity.BudgetStatus) error {
+	if len(exceeded) == 0 || !s.cfg.BudgetEnforce || (sub != nil && sub.AllowOverBudget) {
+		return nil
+	}
+	return budgetError(exceeded)
+}
+
+// budgetError описывает превышенные бюджеты для ответа клиенту
+func budgetError(exceeded []*entity.BudgetStatus) error {
+	parts := make([]string, 0, len(exceeded))
+	for _, status := range exceeded {
+		scope := "all subscriptions"
+		if status.Category != "" {
+			scope = "category " + status.Category
+		} else if status.ServiceName != "" {
+			scope = "service " + status.ServiceName
+		}
+		parts = append(parts, fmt.Sprintf("%s: %d of %d", scope, status.Spent, status.Amount))
+	}
+	return fmt.Errorf("%w (%s), set allow_over_budget to save anyway", ErrBudgetExceeded, strings.Join(parts, "; "))
+}
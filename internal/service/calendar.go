@@ -0,0 +1,94 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"subscribe_service/internal/entity"
+	"subscribe_service/internal/ical"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidToken означает, что токен доступа не подходит к ресурсу
+var ErrInvalidToken = errors.New("invalid token")
+
+const calendarUIDDomain = "subscription-service"
+
+// CalendarToken возвращает токен доступа к календарю пользователя,
+// подписанный секретом secret. Токены выдаются вне HTTP API, см. cmd/calendartoken
+func CalendarToken(secret string, userID uuid.UUID) *entity.CalendarTokenResponse {
+	token := calendarToken(secret, userID)
+	return &entity.CalendarTokenResponse{
+		UserID: userID,
+		Token:  token,
+		URL:    fmt.Sprintf("/api/v1/users/%s/subscriptions.ics?token=%s", userID, token),
+	}
+}
+
+// GetCalendar возвращает календарь продлений и окончаний подписок пользователя
+// на ближайшие месяцы. UID событий выводятся из ID подписки и даты события
+func (s *service) GetCalendar(userID uuid.UUID, token string) (*ical.Calendar, error) {
+	if !hmac.Equal([]byte(token), []byte(calendarToken(s.cfg.CalendarSecret, userID))) {
+		return nil, ErrInvalidToken
+	}
+
+	subs, err := s.repo.List(&entity.ListRequest{UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, s.cfg.CalendarHorizonMonths, 0)
+
+	chargeOn, err := s.chargesOn(subs.Subscriptions, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	cal := &ical.Calendar{
+		ProdID: "-//subscribe_service//Subscriptions//EN",
+		Name:   "Subscriptions",
+	}
+	for _, sub := range subs.Subscriptions {
+		renewalsTo := to
+		// подписка действует и оплачивается до конца месяца окончания
+		if lastDay, ok := sub.LastDay(); ok {
+			if lastDay.Before(from) {
+				continue
+			}
+			if !lastDay.After(to) {
+				cal.Events = append(cal.Events, ical.Event{
+					UID:         fmt.Sprintf("%s-finish@%s", sub.ID, calendarUIDDomain),
+					Date:        lastDay,
+					Summary:     fmt.Sprintf("%s subscription ends", sub.ServiceName),
+					Description: fmt.Sprintf("Subscription %s ends", sub.ID),
+				})
+				renewalsTo = lastDay
+			}
+		}
+
+		start, step := billingSchedule(sub)
+		for _, date := range billingDates(start, from, renewalsTo, step) {
+			cal.Events = append(cal.Events, ical.Event{
+				UID:         fmt.Sprintf("%s-renewal-%s@%s", sub.ID, date.Format("20060102"), calendarUIDDomain),
+				Date:        date,
+				Summary:     fmt.Sprintf("%s renewal: %d", sub.ServiceName, chargeOn(sub, date)),
+				Description: fmt.Sprintf("Subscription %s renews, expected charge %d", sub.ID, chargeOn(sub, date)),
+			})
+		}
+	}
+
+	return cal, nil
+}
+
+// calendarToken подписывает ID пользователя секретом календаря
+func calendarToken(secret string, userID uuid.UUID) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("calendar:" + userID.String()))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"subscribe_service/internal/entity"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const maxCancelCommentLength = 1000
+
+// Cancel отменяет подписку с момента req.Effective. Отменить можно только подписку,
+// которая уже началась, ещё действует и не отменена, а новый месяц окончания не должен
+// быть позже прежнего. Месяц окончания записывается в finish_date, день - в
+// Cancellation.EffectiveDate. Паузы после окончания подписки удаляются, открытая пауза закрывается
+func (s *service) Cancel(id uuid.UUID, req *entity.CancelRequest) (*entity.Subscription, error) {
+	if req.Effective == "" {
+		req.Effective = entity.CancelImmediately
+	}
+	if !slices.Contains(entity.CancelReasons, req.Reason) {
+		return nil, fmt.Errorf("%w: reason must be one of %s", ErrInvalidRequest, strings.Join(entity.CancelReasons, ", "))
+	}
+	if utf8.RuneCountInString(req.Comment) > maxCancelCommentLength {
+		return nil, fmt.Errorf("%w: comment is longer than %d characters", ErrInvalidRequest, maxCancelCommentLength)
+	}
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	lastDay, err := cancelDate(req, today)
+	if err != nil {
+		return nil, err
+	}
+	finish := monthStart(lastDay)
+
+	return s.repo.Cancel(id, func(sub *entity.Subscription) error {
+		if sub.Cancellation != nil {
+			return fmt.Errorf("%w: subscription is already cancelled", ErrInvalidTransition)
+		}
+		if err := checkTransition(sub, entity.StatusCancelled, today); err != nil {
+			return err
+		}
+		if sub.FinishDate != nil && !time.Time(*sub.FinishDate).IsZero() {
+			current := monthStart(time.Time(*sub.FinishDate))
+			if finish.After(current) {
+				return fmt.Errorf("%w: subscription already finishes in %s", ErrInvalidRequest, current.Format("01-2006"))
+			}
+		}
+		if finish.Before(monthStart(time.Time(sub.StartDate))) {
+			return fmt.Errorf("%w: subscription starts in %s, delete it instead", ErrInvalidRequest, time.Time(sub.StartDate).Format("01-2006"))
+		}
+
+		finishDate := entity.CustomDate(finish)
+		sub.FinishDate = &finishDate
+		sub.Pauses = trimPauses(sub.Pauses, finish)
+		sub.Cancellation = &entity.Cancellation{
+			Reason:        req.Reason,
+			Comment:       req.Comment,
+			Effective:     req.Effective,
+			EffectiveDate: lastDay,
+		}
+
+		return validatePauses(sub)
+	})
+}
+
+// GetChurn возвращает отчёт об оттоке по месяцам, причинам и сервисам
+func (s *service) GetChurn(req *entity.ChurnRequest) (*entity.ChurnResponse, error) {
+	if req.StartDate != nil && req.FinishDate != nil && time.Time(*req.StartDate).After(time.Time(*req.FinishDate)) {
+		return nil, fmt.Errorf("%w: start_date is after finish_date", ErrInvalidRequest)
+	}
+
+	items, err := s.repo.GetChurn(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.ChurnResponse{Items: items}, nil
+}
+
+// cancelDate возвращает последний день, по который подписка действует при req.Effective
+func cancelDate(req *entity.CancelRequest, today time.Time) (time.Time, error) {
+	switch req.Effective {
+	case entity.CancelImmediately:
+		return today, nil
+	case entity.CancelEndOfMonth:
+		return monthStart(today).AddDate(0, 1, -1), nil
+	case entity.CancelAtMonth:
+		if req.Month == nil || time.Time(*req.Month).IsZero() {
+			return time.Time{}, fmt.Errorf("%w: month is required for effective=%s", ErrInvalidRequest, entity.CancelAtMonth)
+		}
+		month := monthStart(time.Time(*req.Month))
+		if month.Before(monthStart(today)) {
+			return time.Time{}, fmt.Errorf("%w: month is in the past", ErrInvalidRequest)
+		}
+		return month.AddDate(0, 1, -1), nil
+	default:
+		return time.Time{}, fmt.Errorf("%w: effective must be %s, %s or %s", ErrInvalidRequest,
+			entity.CancelImmediately, entity.CancelEndOfMonth, entity.CancelAtMonth)
+	}
+}
+
+// trimPauses убирает паузы, начинающиеся после месяца last, и заканчивает остальные не позже него
+func trimPauses(pauses []*entity.Pause, last time.Time) []*entity.Pause {
+	trimmed := make([]*entity.Pause, 0, len(pauses))
+	for _, pause := range pauses {
+		if time.Time(pause.StartDate).After(last) {
+			continue
+		}
+		if pause.EndDate == nil || time.Time(*pause.EndDate).IsZero() || time.Time(*pause.EndDate).After(last) {
+			end := entity.CustomDate(last)
+			pause.EndDate = &end
+		}
+		trimmed = append(trimmed, pause)
+	}
+	return trimmed
+}
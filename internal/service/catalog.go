@@ -0,0 +1,175 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"subscribe_service/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+// defaultCurrency - валюта сервиса каталога, если она не указана
+const defaultCurrency = "RUB"
+
+func (s *service) CreateCatalogService(req *entity.CreateCatalogServiceRequest) (*entity.CatalogService, error) {
+	if err := normalizeCatalogService(req); err != nil {
+		return nil, err
+	}
+
+	return s.repo.AddCatalogService(req)
+}
+
+func (s *service) GetCatalogService(id uuid.UUID) (*entity.CatalogService, error) {
+	return s.repo.GetCatalogService(id)
+}
+
+func (s *service) ListCatalogServices(category string) (*entity.CatalogServicesResponse, error) {
+	services, err := s.repo.ListCatalogServices(category)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.CatalogServicesResponse{Services: services}, nil
+}
+
+// UpdateCatalogService обновляет сервис каталога. Новое название
+// переносится во все подписки сервиса
+func (s *service) UpdateCatalogService(svc *entity.CatalogService) (*entity.CatalogService, error) {
+	if svc.ID == uuid.Nil {
+		return nil, fmt.Errorf("%w: id is required", ErrInvalidRequest)
+	}
+
+	req := &entity.CreateCatalogServiceRequest{
+		Name:         svc.Name,
+		Aliases:      svc.Aliases,
+		Category:     svc.Category,
+		DefaultPrice: svc.DefaultPrice,
+		Currency:     svc.Currency,
+		Website:      svc.Website,
+	}
+	if err := normalizeCatalogService(req); err != nil {
+		return nil, err
+	}
+	svc.Name, svc.Aliases, svc.Currency, svc.Category = req.Name, req.Aliases, req.Currency, req.Category
+
+	return s.repo.UpdateCatalogService(svc)
+}
+
+func (s *service) DeleteCatalogService(id uuid.UUID) error {
+	return s.repo.DeleteCatalogService(id)
+}
+
+// normalizeCatalogService проверяет поля сервиса, убирает лишние пробелы
+// и повторы среди псевдонимов
+func normalizeCatalogService(req *entity.CreateCatalogServiceRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidRequest)
+	}
+	if len(req.Name) > 255 {
+		return fmt.Errorf("%w: name is longer than 255 bytes", ErrInvalidRequest)
+	}
+
+	seen := map[string]bool{strings.ToLower(req.Name): true}
+	aliases := make([]string, 0, len(req.Aliases))
+	for _, alias := range req.Aliases {
+		alias = strings.TrimSpace(alias)
+		if alias == "" || seen[strings.ToLower(alias)] {
+			continue
+		}
+		seen[strings.ToLower(alias)] = true
+		aliases = append(aliases, alias)
+	}
+	req.Aliases = aliases
+
+	req.Category = strings.TrimSpace(req.Category)
+
+	req.Currency = strings.ToUpper(strings.TrimSpace(req.Currency))
+	if req.Currency == "" {
+		req.Currency = defaultCurrency
+	}
+	if len(req.Currency) != 3 || strings.Trim(req.Currency, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return fmt.Errorf("%w: currency must be an ISO 4217 code", ErrInvalidRequest)
+	}
+
+	if req.Website != "" {
+		u, err := url.ParseRequestURI(req.Website)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: website must be an absolute http(s) URL", ErrInvalidRequest)
+		}
+	}
+
+	return nil
+}
+
+// serviceResolver находит сервисы каталога для подписок и запоминает
+// найденные, чтобы пакеты и импорт не искали одно имя повторно
+type serviceResolver struct {
+	s      *service
+	byName map[string]*entity.CatalogService
+	byID   map[uuid.UUID]*entity.CatalogService
+}
+
+func (s *service) newServiceResolver() *serviceResolver {
+	return &serviceResolver{
+		s:      s,
+		byName: make(map[string]*entity.CatalogService),
+		byID:   make(map[uuid.UUID]*entity.CatalogService),
+	}
+}
+
+// resolve возвращает сервис по id, а если id не задан - по названию или псевдониму
+// без учёта регистра. Для неизвестного названия, если настройка
+// SERVICE_CATALOG_AUTO_CREATE разрешает добавлять сервисы, возвращается сервис
+// без ID: репозиторий добавит его в каталог в транзакции записи подписки
+func (r *serviceResolver) resolve(id uuid.UUID, name string) (*entity.CatalogService, error) {
+	if id != uuid.Nil {
+		if svc, ok := r.byID[id]; ok {
+			return svc, nil
+		}
+		svc, err := r.s.repo.GetCatalogService(id)
+		if err != nil {
+			return nil, err
+		}
+		r.byID[id] = svc
+		return svc, nil
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: service_name or service_id is required", ErrInvalidRequest)
+	}
+
+	key := strings.ToLower(name)
+	if svc, ok := r.byName[key]; ok {
+		return svc, nil
+	}
+
+	svc, err := r.s.repo.FindCatalogService(name)
+	if err != nil {
+		return nil, err
+	}
+	if svc == nil {
+		if !r.s.cfg.ServiceCatalogAutoCreate {
+			return nil, fmt.Errorf("%w: unknown service %q", ErrInvalidRequest, name)
+		}
+		svc = &entity.CatalogService{Name: name}
+	} else {
+		r.byID[svc.ID] = svc
+	}
+
+	r.byName[key] = svc
+	return svc, nil
+}
+
+// resolveSubscription проставляет подписке сервис каталога и его каноническое название
+func (r *serviceResolver) resolveSubscription(sub *entity.Subscription) error {
+	svc, err := r.resolve(sub.ServiceID, sub.ServiceName)
+	if err != nil {
+		return err
+	}
+	sub.ServiceID = svc.ID
+	sub.ServiceName = svc.Name
+	return nil
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"fmt"
+	"subscribe_service/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultForecastMonths = 12
+	maxForecastMonths     = 120
+)
+
+// GetForecast прогнозирует помесячные расходы на months месяцев начиная с текущего.
+// Бессрочные подписки продлеваются до конца прогноза, известные даты окончания,
+// пробные периоды, паузы, периоды оплаты и запланированные смены цены учитываются
+// так же, как в итогах
+func (s *service) GetForecast(userID uuid.UUID, serviceName string, months int) (*entity.ForecastResponse, error) {
+	if months == 0 {
+		months = defaultForecastMonths
+	}
+	if months < 0 || months > maxForecastMonths {
+		return nil, fmt.Errorf("%w: months must be between 1 and %d", ErrInvalidRequest, maxForecastMonths)
+	}
+
+	series, err := s.repo.GetForecast(&entity.ForecastRequest{
+		UserID:      userID,
+		ServiceName: serviceName,
+		From:        entity.CustomDate(currentMonth()),
+		Months:      months,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &entity.ForecastResponse{
+		UserID:      userID,
+		ServiceName: serviceName,
+		Months:      series,
+	}
+	if len(series) > 0 {
+		resp.Total = series[len(series)-1].Cumulative
+	}
+
+	return resp, nil
+}
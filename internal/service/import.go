@@ -0,0 +1,178 @@
+package service
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"subscribe_service/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+// maxImportErrors ограничивает число ошибок в ответе, чтобы файл
+// из одних ошибок не занимал память целиком
+const maxImportErrors = 1000
+
+var importColumns = []string{"user_id", "service_name", "price", "start_date", "finish_date", "trial_end_date", "billing_period"}
+
+// Import читает CSV с заголовком и проверяет каждую строку. В режиме dryRun
+// только возвращает ошибки, иначе загружает корректные строки в базу
+func (s *service) Import(r io.Reader, dryRun bool) (*entity.ImportResponse, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: empty csv", ErrInvalidRequest)
+		}
+		return nil, fmt.Errorf("%w: reading csv header: %s", ErrInvalidRequest, err)
+	}
+	columns, err := importHeader(header)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &entity.ImportResponse{DryRun: dryRun, Errors: []entity.ImportError{}}
+	addError := func(line int, err error) {
+		resp.Failed++
+		if len(resp.Errors) < maxImportErrors {
+			resp.Errors = append(resp.Errors, entity.ImportError{Line: line, Error: err.Error()})
+		} else {
+			resp.ErrorsTruncated = true
+		}
+	}
+
+	resolver := s.newServiceResolver()
+	parse := func(add func(sub *entity.Subscription) error) error {
+		for {
+			record, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				var parseErr *csv.ParseError
+				if !errors.As(err, &parseErr) {
+					return fmt.Errorf("reading csv error: %w", err)
+				}
+				resp.Total++
+				addError(parseErr.Line, parseErr.Err)
+				continue
+			}
+			resp.Total++
+			line, _ := reader.FieldPos(0)
+
+			sub, err := parseImportRecord(record, columns)
+			if err == nil {
+				err = validateSubscription(sub, false)
+			}
+			if err == nil {
+				err = validateTrial(sub.StartDate, &sub.TrialEndDate)
+			}
+			if err == nil {
+				err = resolver.resolveSubscription(sub)
+			}
+			if err != nil {
+				addError(line, err)
+				continue
+			}
+
+			resp.Valid++
+			if add != nil {
+				if err := add(sub); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if dryRun {
+		if err := parse(nil); err != nil {
+			return nil, err
+		}
+		return resp, nil
+	}
+
+	staged, created, err := s.repo.Import(parse, s.checkBudgets)
+	if err != nil {
+		return nil, err
+	}
+	resp.Created = created
+	resp.Skipped = staged - created
+
+	return resp, nil
+}
+
+// importHeader возвращает номер колонки для каждого поля из importColumns.
+// Колонки finish_date, trial_end_date и billing_period необязательны
+func importHeader(header []string) (map[string]int, error) {
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+
+	for _, name := range importColumns {
+		if _, ok := columns[name]; !ok && name != "finish_date" && name != "trial_end_date" && name != "billing_period" {
+			return nil, fmt.Errorf("%w: csv header must contain %s", ErrInvalidRequest, strings.Join(importColumns, ", "))
+		}
+	}
+
+	return columns, nil
+}
+
+func parseImportRecord(record []string, columns map[string]int) (*entity.Subscription, error) {
+	field := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var sub entity.Subscription
+	var err error
+
+	sub.UserID, err = uuid.Parse(field("user_id"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid user_id: %s", ErrInvalidRequest, err)
+	}
+
+	sub.ServiceName = field("service_name")
+
+	price, err := strconv.ParseUint(field("price"), 10, 31)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid price: %s", ErrInvalidRequest, err)
+	}
+	sub.Price = uint(price)
+
+	sub.StartDate, err = entity.ParseCustomDate(field("start_date"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid start_date: %s", ErrInvalidRequest, err)
+	}
+
+	if s := field("finish_date"); s != "" {
+		finishDate, err := entity.ParseCustomDate(s)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid finish_date: %s", ErrInvalidRequest, err)
+		}
+		sub.FinishDate = &finishDate
+	}
+
+	if s := field("trial_end_date"); s != "" {
+		trialEndDate, err := entity.ParseCustomDate(s)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid trial_end_date: %s", ErrInvalidRequest, err)
+		}
+		sub.TrialEndDate = &trialEndDate
+	}
+
+	sub.BillingPeriod = field("billing_period")
+
+	return &sub, nil
+}
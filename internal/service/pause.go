@@ -0,0 +1,114 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"subscribe_service/internal/entity"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Pause приостанавливает оплату подписки с месяца req.StartDate по req.EndDate
+// включительно. Без EndDate пауза действует до вызова Resume
+func (s *service) Pause(id uuid.UUID, req *entity.PauseRequest) (*entity.Subscription, error) {
+	start := currentMonth()
+	if req.StartDate != nil && !time.Time(*req.StartDate).IsZero() {
+		start = monthStart(time.Time(*req.StartDate))
+	}
+
+	pause := &entity.Pause{ID: uuid.New(), StartDate: entity.CustomDate(start)}
+	if req.EndDate != nil && !time.Time(*req.EndDate).IsZero() {
+		end := entity.CustomDate(monthStart(time.Time(*req.EndDate)))
+		pause.EndDate = &end
+	}
+
+	return s.repo.UpdatePauses(id, entity.EventSubscriptionPaused, func(sub *entity.Subscription) error {
+		if err := checkTransition(sub, entity.StatusPaused, time.Now().UTC()); err != nil {
+			return err
+		}
+
+		sub.Pauses = append(sub.Pauses, pause)
+		slices.SortFunc(sub.Pauses, func(a, b *entity.Pause) int {
+			return time.Time(a.StartDate).Compare(time.Time(b.StartDate))
+		})
+		return validatePauses(sub)
+	})
+}
+
+// Resume возобновляет оплату с месяца req.Date. Пауза, действующая в этом месяце,
+// заканчивается предыдущим месяцем, а если она начинается в этом же месяце - удаляется
+func (s *service) Resume(id uuid.UUID, req *entity.ResumeRequest) (*entity.Subscription, error) {
+	month := currentMonth()
+	if req.Date != nil && !time.Time(*req.Date).IsZero() {
+		month = monthStart(time.Time(*req.Date))
+	}
+
+	return s.repo.UpdatePauses(id, entity.EventSubscriptionResumed, func(sub *entity.Subscription) error {
+		if err := checkTransition(sub, entity.StatusActive, time.Now().UTC()); err != nil {
+			return err
+		}
+
+		i := slices.IndexFunc(sub.Pauses, func(p *entity.Pause) bool { return p.Covers(month) })
+		if i < 0 {
+			return fmt.Errorf("%w: subscription is not paused in %s", ErrInvalidRequest, month.Format("01-2006"))
+		}
+
+		pause := sub.Pauses[i]
+		if time.Time(pause.StartDate).Equal(month) {
+			sub.Pauses = slices.Delete(sub.Pauses, i, i+1)
+			return nil
+		}
+
+		end := entity.CustomDate(month.AddDate(0, -1, 0))
+		pause.EndDate = &end
+		return nil
+	})
+}
+
+// validatePauses проверяет, что паузы лежат в пределах подписки и не пересекаются.
+// Паузы должны быть отсортированы по началу
+func validatePauses(sub *entity.Subscription) error {
+	start := monthStart(time.Time(sub.StartDate))
+	var finish time.Time
+	if sub.FinishDate != nil && !time.Time(*sub.FinishDate).IsZero() {
+		finish = monthStart(time.Time(*sub.FinishDate))
+	}
+
+	for i, pause := range sub.Pauses {
+		pauseStart := time.Time(pause.StartDate)
+		if pauseStart.Before(start) {
+			return fmt.Errorf("%w: pause starts before subscription start_date", ErrInvalidRequest)
+		}
+		if !finish.IsZero() && pauseStart.After(finish) {
+			return fmt.Errorf("%w: pause starts after subscription finish_date", ErrInvalidRequest)
+		}
+
+		if pause.EndDate != nil {
+			pauseEnd := time.Time(*pause.EndDate)
+			if pauseEnd.Before(pauseStart) {
+				return fmt.Errorf("%w: pause end_date is before start_date", ErrInvalidRequest)
+			}
+			if !finish.IsZero() && pauseEnd.After(finish) {
+				return fmt.Errorf("%w: pause ends after subscription finish_date", ErrInvalidRequest)
+			}
+		}
+
+		if i > 0 {
+			prev := sub.Pauses[i-1]
+			if prev.EndDate == nil || !time.Time(*prev.EndDate).Before(pauseStart) {
+				return fmt.Errorf("%w: pause overlaps pause from %s", ErrInvalidRequest, time.Time(prev.StartDate).Format("01-2006"))
+			}
+		}
+	}
+
+	return nil
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func currentMonth() time.Time {
+	return monthStart(time.Now().UTC())
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"subscribe_service/internal/entity"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SchedulePriceChange планирует смену цены подписки с месяца req.EffectiveDate.
+// Смена в том же месяце заменяется новой. Прошлые месяцы не меняются,
+// поэтому месяц не может быть раньше текущего
+func (s *service) SchedulePriceChange(id uuid.UUID, req *entity.PriceChangeRequest) (*entity.Subscription, error) {
+	if time.Time(req.EffectiveDate).IsZero() {
+		return nil, fmt.Errorf("%w: effective_date is required", ErrInvalidRequest)
+	}
+	month := monthStart(time.Time(req.EffectiveDate))
+	if month.Before(currentMonth()) {
+		return nil, fmt.Errorf("%w: effective_date is in the past", ErrInvalidRequest)
+	}
+
+	change := &entity.PriceChange{
+		EffectiveDate: entity.CustomDate(month),
+		Price:         req.Price,
+		CreatedAt:     time.Now().UTC(),
+	}
+
+	return s.repo.UpdatePriceChanges(id, func(sub *entity.Subscription) error {
+		if sub.FinishDate != nil && !time.Time(*sub.FinishDate).IsZero() &&
+			month.After(monthStart(time.Time(*sub.FinishDate))) {
+			return fmt.Errorf("%w: effective_date is after subscription finish_date", ErrInvalidRequest)
+		}
+		if month.Before(monthStart(time.Time(sub.StartDate))) {
+			return fmt.Errorf("%w: effective_date is before subscription start_date", ErrInvalidRequest)
+		}
+
+		sub.PriceChanges = slices.DeleteFunc(sub.PriceChanges, func(c *entity.PriceChange) bool {
+			return time.Time(c.EffectiveDate).Equal(month)
+		})
+		sub.PriceChanges = append(sub.PriceChanges, change)
+		slices.SortFunc(sub.PriceChanges, func(a, b *entity.PriceChange) int {
+			return time.Time(a.EffectiveDate).Compare(time.Time(b.EffectiveDate))
+		})
+		return nil
+	})
+}
+
+// CancelPriceChange отменяет смену цены с месяца month. Уже действующие
+// смены прошлых месяцев не отменяются
+func (s *service) CancelPriceChange(id uuid.UUID, month time.Time) (*entity.Subscription, error) {
+	month = monthStart(month)
+	if month.Before(currentMonth()) {
+		return nil, fmt.Errorf("%w: price change in %s is already applied", ErrInvalidRequest, month.Format("01-2006"))
+	}
+
+	return s.repo.UpdatePriceChanges(id, func(sub *entity.Subscription) error {
+		i := slices.IndexFunc(sub.PriceChanges, func(c *entity.PriceChange) bool {
+			return time.Time(c.EffectiveDate).Equal(month)
+		})
+		if i < 0 {
+			return fmt.Errorf("%w: no price change in %s", ErrInvalidRequest, month.Format("01-2006"))
+		}
+
+		sub.PriceChanges = slices.Delete(sub.PriceChanges, i, i+1)
+		return nil
+	})
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"subscribe_service/internal/entity"
+	"unicode"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// Search ищет подписки по части названия сервиса и его псевдонимам с учётом опечаток
+func (s *service) Search(req *entity.SearchRequest) (*entity.SearchResponse, error) {
+	req.Query = strings.TrimSpace(req.Query)
+	if strings.IndexFunc(req.Query, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0 {
+		return nil, fmt.Errorf("%w: q must contain letters or digits", ErrInvalidRequest)
+	}
+	if len(req.Query) > 255 {
+		return nil, fmt.Errorf("%w: q is longer than 255 bytes", ErrInvalidRequest)
+	}
+
+	if req.Limit == 0 {
+		req.Limit = defaultSearchLimit
+	}
+	if req.Limit < 0 || req.Limit > maxSearchLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidRequest, maxSearchLimit)
+	}
+	if req.Offset < 0 {
+		return nil, fmt.Errorf("%w: offset must not be negative", ErrInvalidRequest)
+	}
+
+	return s.repo.Search(req)
+}
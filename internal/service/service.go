@@ -1,51 +1,240 @@
 package service
 
 import (
+	"errors"
+	"fmt"
 	"subscribe_service/internal/config"
 	"subscribe_service/internal/entity"
-	repository "subscribe_service/internal/repository/postgres"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+// ErrInvalidRequest означает, что запрос не прошёл валидацию
+var ErrInvalidRequest = errors.New("invalid request")
+
 type Repository interface {
-	Add(sub *entity.CreateRequest) (*entity.Subscription, error)
+	Add(sub *entity.CreateRequest, check func(sub *entity.Subscription, exceeded []*entity.BudgetStatus) error) (*entity.Subscription, error)
 	GetRecordByID(id uuid.UUID) (*entity.Subscription, error)
-	Update(sub *entity.Subscription) (*entity.Subscription, error)
+	GetRecordAt(id uuid.UUID, at time.Time) (*entity.Subscription, error)
+	Update(sub *entity.Subscription, fn func(current, sub *entity.Subscription) error,
+		check func(sub *entity.Subscription, exceeded []*entity.BudgetStatus) error) (*entity.Subscription, error)
 	Delete(id uuid.UUID) error
-	List() (*entity.SubscriptionsResponse, error)
+	List(req *entity.ListRequest) (*entity.SubscriptionsResponse, error)
 	GetTotal(req *entity.TotalRequest) (*entity.TotalResponse, error)
+	GetForecast(req *entity.ForecastRequest) ([]*entity.ForecastMonth, error)
+	GetMRR(req *entity.AnalyticsRequest) ([]*entity.MRRPoint, error)
+	GetChurnRate(req *entity.AnalyticsRequest) ([]*entity.ChurnRatePoint, error)
+	GetCohorts(req *entity.AnalyticsRequest) ([]*entity.CohortRow, error)
+	ListUpcoming(req *entity.UpcomingRequest) ([]*entity.Subscription, error)
+	GetMonthlyCharges(ids []uuid.UUID, from, to time.Time) ([]*entity.MonthlyCharge, error)
+	Batch(ops []*entity.BatchOperation, atomic bool, update func(current, sub *entity.Subscription) error,
+		check func(sub *entity.Subscription, exceeded []*entity.BudgetStatus) error) ([]*entity.BatchItemResult, error)
+	Import(fn func(add func(sub *entity.Subscription) error) error,
+		check func(sub *entity.Subscription, exceeded []*entity.BudgetStatus) error) (staged, created int, err error)
+	Export(req *entity.ListRequest, fn func(sub *entity.Subscription) error) error
+	GetUserSummary(userID uuid.UUID, month time.Time, top int) (*entity.UserSummary, error)
+	Search(req *entity.SearchRequest) (*entity.SearchResponse, error)
+	ListTrialsEnding(req *entity.TrialsRequest) ([]*entity.Subscription, error)
+	UpdatePauses(id uuid.UUID, eventType string, fn func(sub *entity.Subscription) error) (*entity.Subscription, error)
+	UpdatePriceChanges(id uuid.UUID, fn func(sub *entity.Subscription) error) (*entity.Subscription, error)
+	Cancel(id uuid.UUID, fn func(sub *entity.Subscription) error) (*entity.Subscription, error)
+	GetChurn(req *entity.ChurnRequest) ([]*entity.ChurnItem, error)
+
+	AddCatalogService(req *entity.CreateCatalogServiceRequest) (*entity.CatalogService, error)
+	GetCatalogService(id uuid.UUID) (*entity.CatalogService, error)
+	FindCatalogService(name string) (*entity.CatalogService, error)
+	ListCatalogServices(category string) ([]*entity.CatalogService, error)
+	UpdateCatalogService(svc *entity.CatalogService) (*entity.CatalogService, error)
+	DeleteCatalogService(id uuid.UUID) error
+
+	AddTag(name string) (*entity.Tag, error)
+	ListTags() ([]*entity.Tag, error)
+	RenameTag(id uuid.UUID, name string) (*entity.Tag, error)
+	DeleteTag(id uuid.UUID) error
+
+	AddBudget(budget *entity.Budget) (*entity.Budget, error)
+	ListBudgets(userID uuid.UUID) ([]*entity.Budget, error)
+	UpdateBudget(budget *entity.Budget) (*entity.Budget, error)
+	DeleteBudget(userID, id uuid.UUID) error
+	GetBudgetStatus(userID uuid.UUID, month time.Time) ([]*entity.BudgetStatus, error)
+
+	AddWebhookEndpoint(req *entity.CreateWebhookRequest) (*entity.WebhookEndpoint, error)
+	ListWebhookEndpoints() ([]*entity.WebhookEndpoint, error)
+	DeleteWebhookEndpoint(id uuid.UUID) error
+	ListWebhookDeliveries(req *entity.WebhookDeliveriesRequest) (*entity.WebhookDeliveriesResponse, error)
+	RedeliverWebhook(id uuid.UUID) error
 }
 
 type service struct {
+	cfg  *config.Config
 	repo Repository
+	// reads - хранилище для чтения подписок, итогов, выгрузки и аналитики,
+	// которое может отставать от repo, например реплика
+	reads Repository
 }
 
-func NewService(cfg *config.Config) *service {
+func NewService(cfg *config.Config, repo, reads Repository) *service {
 	return &service{
-		repo: repository.NewRepository(cfg),
+		cfg:   cfg,
+		repo:  repo,
+		reads: reads,
 	}
 }
+
+// Create создаёт подписку на сервис из каталога, найденный по service_id или service_name.
+// Бюджеты проверяются в транзакции записи (см. checkBudgets)
 func (s *service) Create(req *entity.CreateRequest) (*entity.Subscription, error) {
-	return s.repo.Add(req)
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+	req.Tags = tags
+
+	if err := validateTrial(req.StartDate, &req.TrialEndDate); err != nil {
+		return nil, err
+	}
+	if err := normalizeBillingPeriod(&req.BillingPeriod); err != nil {
+		return nil, err
+	}
+
+	resolver := s.newServiceResolver()
+	svc, err := resolver.resolve(req.ServiceID, req.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+	req.ServiceID = svc.ID
+	req.ServiceName = svc.Name
+
+	return s.repo.Add(req, s.checkBudgets)
 }
 
-func (s *service) GetRecordByID(id uuid.UUID) (*entity.Subscription, error) {
-	return s.repo.GetRecordByID(id)
+// GetRecordByID возвращает подписку со статусом на месяц asOf или на текущий месяц.
+// С history подписка возвращается в том виде, в каком была на конец месяца asOf
+func (s *service) GetRecordByID(id uuid.UUID, asOf *entity.CustomDate, history bool) (*entity.Subscription, error) {
+	if err := validateHistory(asOf, history); err != nil {
+		return nil, err
+	}
+
+	var sub *entity.Subscription
+	var err error
+	if history {
+		sub, err = s.reads.GetRecordAt(id, monthStart(time.Time(*asOf)).AddDate(0, 1, 0))
+	} else {
+		sub, err = s.reads.GetRecordByID(id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	statusAsOf(asOf, sub)
+
+	return sub, nil
 }
 
+// Update обновляет подписку. Изменение проверяется под блокировкой подписки
+// (см. prepareUpdate), бюджеты - так же, как в Create
 func (s *service) Update(req *entity.Subscription) (*entity.Subscription, error) {
-	return s.repo.Update(req)
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+	req.Tags = tags
+
+	if err := validateTrial(req.StartDate, &req.TrialEndDate); err != nil {
+		return nil, err
+	}
+	if err := normalizeBillingPeriod(&req.BillingPeriod); err != nil {
+		return nil, err
+	}
+
+	resolver := s.newServiceResolver()
+	if err := resolver.resolveSubscription(req); err != nil {
+		return nil, err
+	}
+
+	return s.repo.Update(req, prepareUpdate, s.checkBudgets)
+}
+
+// prepareUpdate переносит в sub то, что обновление не меняет, из текущего состояния
+// подписки current и проверяет результат, в том числе допустимость смены статуса.
+// Общая для Update и Batch. Вызывается под блокировкой подписки
+// в транзакции записи, поэтому current не может устареть до сохранения
+func prepareUpdate(current, sub *entity.Subscription) error {
+	// паузы меняются только через Pause и Resume, но должны остаться в пределах новых дат
+	sub.Pauses = current.Pauses
+	if err := validatePauses(sub); err != nil {
+		return err
+	}
+	// смены цены меняются только через price-changes
+	sub.PriceChanges = current.PriceChanges
+
+	// без даты окончания отмена снимается, иначе сохраняется
+	sub.Cancellation = nil
+	if sub.FinishDate != nil && !time.Time(*sub.FinishDate).IsZero() {
+		sub.Cancellation = current.Cancellation
+	}
+
+	now := time.Now().UTC()
+	return checkTransition(current, sub.StatusAt(now), now)
 }
 
 func (s *service) Delete(id uuid.UUID) error {
 	return s.repo.Delete(id)
 }
 
-func (s *service) GetList() (*entity.SubscriptionsResponse, error) {
-	return s.repo.List()
+// GetList возвращает подписки со статусом на месяц req.AsOf или на текущий месяц
+func (s *service) GetList(req *entity.ListRequest) (*entity.SubscriptionsResponse, error) {
+	if err := validateStatus(req.Status); err != nil {
+		return nil, err
+	}
+	if err := validateHistory(req.AsOf, req.History); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.reads.List(req)
+	if err != nil {
+		return nil, err
+	}
+	statusAsOf(req.AsOf, resp.Subscriptions...)
+
+	return resp, nil
 }
 
+// Export передаёт в fn подписки, подходящие под фильтры, не загружая их все в память
+func (s *service) Export(req *entity.ListRequest, fn func(sub *entity.Subscription) error) error {
+	if err := validateStatus(req.Status); err != nil {
+		return err
+	}
+
+	return s.reads.Export(req, func(sub *entity.Subscription) error {
+		statusAsOf(req.AsOf, sub)
+		return fn(sub)
+	})
+}
+
+// GetTotal возвращает сумму списаний за период. Если конец периода не задан,
+// итог считается по текущий месяц включительно. as_of задаёт период из одного месяца
 func (s *service) GetTotal(req *entity.TotalRequest) (*entity.TotalResponse, error) {
-	return s.repo.GetTotal(req)
+	if req.AsOf != nil && !time.Time(*req.AsOf).IsZero() {
+		if req.StartDate != nil || req.FinishDate != nil {
+			return nil, fmt.Errorf("%w: as_of can't be combined with start_date and finish_date", ErrInvalidRequest)
+		}
+		month := entity.CustomDate(monthStart(time.Time(*req.AsOf)))
+		req.StartDate, req.FinishDate = &month, &month
+	}
+
+	if req.FinishDate == nil || time.Time(*req.FinishDate).IsZero() {
+		now := time.Now().UTC()
+		month := entity.CustomDate(time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC))
+		req.FinishDate = &month
+	}
+	if req.StartDate != nil && time.Time(*req.StartDate).After(time.Time(*req.FinishDate)) {
+		return nil, fmt.Errorf("%w: start_date is after finish_date", ErrInvalidRequest)
+	}
+
+	if req.GroupBy != "" && req.GroupBy != entity.GroupByTag && req.GroupBy != entity.GroupByCategory {
+		return nil, fmt.Errorf("%w: group_by must be %s or %s", ErrInvalidRequest, entity.GroupByTag, entity.GroupByCategory)
+	}
+
+	return s.reads.GetTotal(req)
 }
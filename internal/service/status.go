@@ -0,0 +1,63 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"subscribe_service/internal/entity"
+	"time"
+)
+
+// ErrInvalidTransition означает недопустимую смену статуса подписки
+var ErrInvalidTransition = fmt.Errorf("%w: invalid status transition", ErrInvalidRequest)
+
+// transitions - статусы, в которые подписка может перейти из текущего.
+// До конца месяца окончания отменённая подписка действует, и отмену можно снять.
+// Закончившаяся подписка, отменённая или нет, больше не меняет статус
+var transitions = map[string][]string{
+	entity.StatusScheduled: {entity.StatusScheduled, entity.StatusTrialing, entity.StatusActive, entity.StatusPaused, entity.StatusExpired},
+	entity.StatusTrialing:  {entity.StatusTrialing, entity.StatusActive, entity.StatusPaused, entity.StatusCancelled, entity.StatusExpired},
+	entity.StatusActive:    {entity.StatusActive, entity.StatusTrialing, entity.StatusPaused, entity.StatusCancelled, entity.StatusExpired},
+	entity.StatusPaused:    {entity.StatusPaused, entity.StatusActive, entity.StatusTrialing, entity.StatusCancelled, entity.StatusExpired},
+	entity.StatusCancelled: {entity.StatusCancelled},
+	entity.StatusExpired:   {entity.StatusExpired},
+}
+
+// checkTransition проверяет, что подписка sub может перейти в статус to в месяце now
+func checkTransition(sub *entity.Subscription, to string, now time.Time) error {
+	from := sub.StatusAt(now)
+	if from == entity.StatusCancelled && to != from {
+		return fmt.Errorf("%w: subscription was cancelled and finished in %s, create a new one instead",
+			ErrInvalidTransition, time.Time(*sub.FinishDate).Format("01-2006"))
+	}
+	if !slices.Contains(transitions[from], to) {
+		return fmt.Errorf("%w: %s subscription can't become %s", ErrInvalidTransition, from, to)
+	}
+	return nil
+}
+
+// validateStatus проверяет фильтр по статусу
+func validateStatus(status string) error {
+	if status != "" && !slices.Contains(entity.Statuses, status) {
+		return fmt.Errorf("%w: status must be one of %s", ErrInvalidRequest, strings.Join(entity.Statuses, ", "))
+	}
+	return nil
+}
+
+// validateHistory проверяет, что история запрашивается на конкретный месяц
+func validateHistory(asOf *entity.CustomDate, history bool) error {
+	if history && (asOf == nil || time.Time(*asOf).IsZero()) {
+		return fmt.Errorf("%w: history requires as_of", ErrInvalidRequest)
+	}
+	return nil
+}
+
+// statusAsOf пересчитывает статус подписок на месяц asOf, если он задан
+func statusAsOf(asOf *entity.CustomDate, subs ...*entity.Subscription) {
+	if asOf == nil || time.Time(*asOf).IsZero() {
+		return
+	}
+	for _, sub := range subs {
+		sub.Status = sub.StatusAt(time.Time(*asOf))
+	}
+}
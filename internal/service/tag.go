@@ -0,0 +1,86 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"subscribe_service/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// maxTagLength - максимальная длина тега в символах
+	maxTagLength = 64
+	// maxTagsPerSubscription ограничивает число тегов у одной подписки
+	maxTagsPerSubscription = 20
+)
+
+func (s *service) CreateTag(req *entity.TagRequest) (*entity.Tag, error) {
+	name, err := normalizeTag(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.AddTag(name)
+}
+
+func (s *service) ListTags() (*entity.TagsResponse, error) {
+	tags, err := s.repo.ListTags()
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.TagsResponse{Tags: tags}, nil
+}
+
+func (s *service) RenameTag(id uuid.UUID, req *entity.TagRequest) (*entity.Tag, error) {
+	name, err := normalizeTag(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.RenameTag(id, name)
+}
+
+func (s *service) DeleteTag(id uuid.UUID) error {
+	return s.repo.DeleteTag(id)
+}
+
+func normalizeTag(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("%w: tag name is required", ErrInvalidRequest)
+	}
+	if len([]rune(name)) > maxTagLength {
+		return "", fmt.Errorf("%w: tag %q is longer than %d characters", ErrInvalidRequest, name, maxTagLength)
+	}
+	return name, nil
+}
+
+// normalizeTags проверяет теги подписки и убирает повторы без учёта регистра.
+// nil остаётся nil, чтобы обновление без поля tags не меняло теги
+func normalizeTags(tags []string) ([]string, error) {
+	if tags == nil {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		name, err := normalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		result = append(result, name)
+	}
+
+	if len(result) > maxTagsPerSubscription {
+		return nil, fmt.Errorf("%w: subscription can't have more than %d tags", ErrInvalidRequest, maxTagsPerSubscription)
+	}
+
+	return result, nil
+}
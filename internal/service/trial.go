@@ -0,0 +1,86 @@
+package service
+
+import (
+	"fmt"
+	"subscribe_service/internal/entity"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// validateTrial проверяет окончание пробного периода: оно не может быть
+// раньше начала подписки. Пустая дата означает подписку без пробного периода
+func validateTrial(start entity.CustomDate, trialEndDate **entity.CustomDate) error {
+	if *trialEndDate == nil || time.Time(**trialEndDate).IsZero() {
+		*trialEndDate = nil
+		return nil
+	}
+	if time.Time(**trialEndDate).Before(time.Time(start)) {
+		return fmt.Errorf("%w: trial_end_date is before start_date", ErrInvalidRequest)
+	}
+
+	return nil
+}
+
+// chargesOn возвращает функцию, которая даёт списание по подписке из subs в дату
+// с from по to. Списания считает репозиторий по тем же правилам, что и итоги:
+// месяцы пробного периода и паузы не оплачиваются
+func (s *service) chargesOn(subs []*entity.Subscription, from, to time.Time) (func(sub *entity.Subscription, date time.Time) uint, error) {
+	type key struct {
+		id    uuid.UUID
+		month string
+	}
+	charges := map[key]uint{}
+
+	if len(subs) > 0 {
+		ids := make([]uuid.UUID, 0, len(subs))
+		for _, sub := range subs {
+			ids = append(ids, sub.ID)
+		}
+		list, err := s.repo.GetMonthlyCharges(ids, monthStart(from), monthStart(to))
+		if err != nil {
+			return nil, err
+		}
+		for _, charge := range list {
+			charges[key{charge.SubscriptionID, charge.Month.Format("2006-01")}] = charge.Charge
+		}
+	}
+
+	return func(sub *entity.Subscription, date time.Time) uint {
+		return charges[key{sub.ID, date.Format("2006-01")}]
+	}, nil
+}
+
+// GetTrialsEnding возвращает подписки, пробный период которых заканчивается
+// в ближайшие within от текущей даты
+func (s *service) GetTrialsEnding(userID uuid.UUID, within time.Duration) (*entity.TrialsResponse, error) {
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	req := &entity.TrialsRequest{
+		UserID: userID,
+		From:   from,
+		To:     from.Add(within),
+	}
+
+	subs, err := s.repo.ListTrialsEnding(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &entity.TrialsResponse{
+		From:   req.From,
+		To:     req.To,
+		Trials: make([]*entity.TrialItem, 0, len(subs)),
+	}
+	for _, sub := range subs {
+		trialEnd := time.Time(*sub.TrialEndDate)
+		resp.Trials = append(resp.Trials, &entity.TrialItem{
+			Subscription: sub,
+			TrialEndDate: trialEnd,
+			DaysLeft:     int(trialEnd.Sub(req.From).Hours() / 24),
+			FirstCharge:  sub.Price,
+		})
+	}
+
+	return resp, nil
+}
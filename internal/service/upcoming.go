@@ -0,0 +1,120 @@
+package service
+
+import (
+	"sort"
+	"subscribe_service/internal/entity"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GetUpcoming возвращает подписки, которые истекают или продлеваются
+// в ближайшие within от текущей даты
+func (s *service) GetUpcoming(userID uuid.UUID, serviceName string, within time.Duration) (*entity.UpcomingResponse, error) {
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	req := &entity.UpcomingRequest{
+		UserID:      userID,
+		ServiceName: serviceName,
+		From:        from,
+		To:          from.Add(within),
+	}
+
+	subs, err := s.repo.ListUpcoming(req)
+	if err != nil {
+		return nil, err
+	}
+
+	chargeOn, err := s.chargesOn(subs, req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &entity.UpcomingResponse{
+		From:  req.From,
+		To:    req.To,
+		Items: make([]*entity.UpcomingItem, 0, len(subs)),
+	}
+	for _, sub := range subs {
+		item := &entity.UpcomingItem{
+			Subscription: sub,
+		}
+
+		if lastDay, ok := sub.LastDay(); ok {
+			item.Event = entity.UpcomingExpiring
+			item.EventDate = lastDay
+		} else {
+			item.Event = entity.UpcomingRenewing
+			start, step := billingSchedule(sub)
+			item.EventDate = nextBillingDate(start, req.From, step)
+			if item.EventDate.After(req.To) {
+				continue
+			}
+		}
+
+		item.ExpectedCharge = chargeOn(sub, item.EventDate)
+		resp.Items = append(resp.Items, item)
+	}
+
+	sort.SliceStable(resp.Items, func(i, j int) bool {
+		return resp.Items[i].EventDate.Before(resp.Items[j].EventDate)
+	})
+
+	return resp, nil
+}
+
+// billingSchedule возвращает дату, от которой отсчитываются списания подписки,
+// и число месяцев между списаниями. Ежемесячные списания идут в день начала
+// подписки, годовые - раз в год с первого платного месяца, как в monthlyChargesSQL
+func billingSchedule(sub *entity.Subscription) (time.Time, int) {
+	if sub.BillingPeriod != entity.BillingYearly {
+		return time.Time(sub.StartDate), 1
+	}
+	if sub.TrialEndDate != nil && !time.Time(*sub.TrialEndDate).IsZero() {
+		return time.Time(*sub.TrialEndDate), 12
+	}
+	return time.Time(sub.StartDate), 12
+}
+
+// nextBillingDate возвращает первую дату списания не раньше from, если списания
+// идут каждые step месяцев с даты start. В коротких месяцах списание
+// переносится на последний день месяца
+func nextBillingDate(start, from time.Time, step int) time.Time {
+	if !start.Before(from) {
+		return start
+	}
+
+	months := (from.Year()-start.Year())*12 + int(from.Month()-start.Month())
+	months -= months % step
+	for {
+		date := addMonths(start, months)
+		if !date.Before(from) {
+			return date
+		}
+		months += step
+	}
+}
+
+// billingDates возвращает даты списаний в интервале [from, to]
+func billingDates(start, from, to time.Time, step int) []time.Time {
+	var dates []time.Time
+	first := nextBillingDate(start, from, step)
+	months := (first.Year()-start.Year())*12 + int(first.Month()-start.Month())
+	for date := first; !date.After(to); date = addMonths(start, months) {
+		dates = append(dates, date)
+		months += step
+	}
+	return dates
+}
+
+// addMonths прибавляет к дате месяцы, не перескакивая в следующий месяц
+// при отсутствии нужного дня
+func addMonths(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"fmt"
+	"subscribe_service/internal/entity"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// topServicesCount - количество сервисов в сводке пользователя
+const topServicesCount = 5
+
+// CreateForUser создаёт подписку пользователя userID. user_id из тела
+// запроса, если передан, должен совпадать с userID
+func (s *service) CreateForUser(userID uuid.UUID, req *entity.CreateRequest) (*entity.Subscription, error) {
+	if req.UserID != uuid.Nil && req.UserID != userID {
+		return nil, fmt.Errorf("%w: user_id in body doesn't match user_id in path", ErrInvalidRequest)
+	}
+	req.UserID = userID
+
+	return s.Create(req)
+}
+
+// GetUserSummary возвращает сводку расходов пользователя за текущий месяц
+func (s *service) GetUserSummary(userID uuid.UUID) (*entity.UserSummary, error) {
+	now := time.Now().UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	return s.repo.GetUserSummary(userID, month, topServicesCount)
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"subscribe_service/internal/entity"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// validateSubscription проверяет обязательные поля подписки, диапазон дат
+// и период оплаты
+func validateSubscription(sub *entity.Subscription, requireID bool) error {
+	if requireID && sub.ID == uuid.Nil {
+		return fmt.Errorf("%w: id is required", ErrInvalidRequest)
+	}
+
+	if sub.UserID == uuid.Nil {
+		return fmt.Errorf("%w: user_id is required", ErrInvalidRequest)
+	}
+
+	if sub.ServiceName == "" && sub.ServiceID == uuid.Nil {
+		return fmt.Errorf("%w: service_name or service_id is required", ErrInvalidRequest)
+	}
+
+	if len(sub.ServiceName) > 255 {
+		return fmt.Errorf("%w: service_name is longer than 255 bytes", ErrInvalidRequest)
+	}
+
+	start := time.Time(sub.StartDate)
+	if start.IsZero() {
+		return fmt.Errorf("%w: start_date is required", ErrInvalidRequest)
+	}
+
+	if sub.FinishDate != nil && !time.Time(*sub.FinishDate).IsZero() && time.Time(*sub.FinishDate).Before(start) {
+		return fmt.Errorf("%w: finish_date is before start_date", ErrInvalidRequest)
+	}
+
+	return normalizeBillingPeriod(&sub.BillingPeriod)
+}
+
+// normalizeBillingPeriod проверяет период оплаты. Пустой период означает ежемесячную оплату
+func normalizeBillingPeriod(period *string) error {
+	*period = strings.ToLower(strings.TrimSpace(*period))
+	switch *period {
+	case "":
+		*period = entity.BillingMonthly
+	case entity.BillingMonthly, entity.BillingYearly:
+	default:
+		return fmt.Errorf("%w: billing_period must be %s or %s", ErrInvalidRequest, entity.BillingMonthly, entity.BillingYearly)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"subscribe_service/internal/entity"
+	"subscribe_service/internal/webhook"
+
+	"github.com/google/uuid"
+)
+
+func (s *service) CreateWebhook(req *entity.CreateWebhookRequest) (*entity.WebhookEndpoint, error) {
+	u, err := url.ParseRequestURI(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidRequest)
+	}
+
+	for _, event := range req.Events {
+		if !slices.Contains(entity.EventTypes, event) {
+			return nil, fmt.Errorf("%w: unknown event type %q", ErrInvalidRequest, event)
+		}
+	}
+
+	if req.Secret == "" {
+		req.Secret, err = webhook.NewSecret()
+		if err != nil {
+			return nil, fmt.Errorf("generating webhook secret error: %w", err)
+		}
+	}
+
+	return s.repo.AddWebhookEndpoint(req)
+}
+
+func (s *service) ListWebhooks() (*entity.WebhookEndpointsResponse, error) {
+	endpoints, err := s.repo.ListWebhookEndpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	// секрет показывается только при создании
+	for _, endpoint := range endpoints {
+		endpoint.Secret = ""
+	}
+
+	return &entity.WebhookEndpointsResponse{Endpoints: endpoints}, nil
+}
+
+func (s *service) DeleteWebhook(id uuid.UUID) error {
+	return s.repo.DeleteWebhookEndpoint(id)
+}
+
+const (
+	defaultDeliveriesLimit = 100
+	maxDeliveriesLimit     = 1000
+)
+
+// ListWebhookDeliveries возвращает страницу доставок вебхуков. Доставленные
+// доставки не удаляются, поэтому список всегда ограничен limit
+func (s *service) ListWebhookDeliveries(req *entity.WebhookDeliveriesRequest) (*entity.WebhookDeliveriesResponse, error) {
+	if req.Status != "" && req.Status != entity.DeliveryPending && req.Status != entity.DeliveryDelivered && req.Status != entity.DeliveryDead {
+		return nil, fmt.Errorf("%w: unknown delivery status %q", ErrInvalidRequest, req.Status)
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultDeliveriesLimit
+	}
+	if req.Limit < 0 || req.Limit > maxDeliveriesLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidRequest, maxDeliveriesLimit)
+	}
+	if req.Offset < 0 {
+		return nil, fmt.Errorf("%w: offset must not be negative", ErrInvalidRequest)
+	}
+
+	return s.repo.ListWebhookDeliveries(req)
+}
+
+func (s *service) RedeliverWebhook(id uuid.UUID) error {
+	return s.repo.RedeliverWebhook(id)
+}
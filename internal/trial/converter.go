@@ -0,0 +1,55 @@
+package trial
+
+import (
+	"context"
+	"log"
+	"subscribe_service/internal/config"
+	"time"
+)
+
+type Store interface {
+	ConvertTrials(today time.Time) (int, error)
+}
+
+// Converter периодически находит подписки с закончившимся пробным периодом
+// и отправляет по ним событие конверсии в платную подписку
+type Converter struct {
+	store    Store
+	interval time.Duration
+}
+
+func NewConverter(cfg *config.Config, store Store) *Converter {
+	return &Converter{
+		store:    store,
+		interval: cfg.TrialCheckInterval,
+	}
+}
+
+// Run проверяет пробные периоды сразу после запуска и затем раз в interval
+// до отмены контекста
+func (c *Converter) Run(ctx context.Context) {
+	log.Println("Start trial converter...")
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Trial converter stopped")
+			return
+		case <-timer.C:
+		}
+
+		now := time.Now().UTC()
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		converted, err := c.store.ConvertTrials(today)
+		if err != nil {
+			log.Println("converting trials error:", err)
+		} else if converted > 0 {
+			log.Printf("converted %d trials\n", converted)
+		}
+
+		timer.Reset(c.interval)
+	}
+}
@@ -0,0 +1,133 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"subscribe_service/internal/config"
+	"subscribe_service/internal/entity"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	batchSize  = 50
+	maxBackoff = 6 * time.Hour
+)
+
+type Store interface {
+	ClaimWebhookDeliveries(limit int, lease time.Duration) ([]*entity.WebhookDelivery, error)
+	MarkWebhookDelivered(id uuid.UUID) error
+	MarkWebhookFailed(id uuid.UUID, lastError string, nextAttemptAt time.Time, dead bool) error
+}
+
+// Dispatcher периодически забирает доставки из очереди и отправляет их получателям
+type Dispatcher struct {
+	store        Store
+	client       *http.Client
+	pollInterval time.Duration
+	maxAttempts  int
+	backoffBase  time.Duration
+}
+
+func NewDispatcher(cfg *config.Config, store Store) *Dispatcher {
+	return &Dispatcher{
+		store:        store,
+		client:       &http.Client{Timeout: cfg.WebhookTimeout},
+		pollInterval: cfg.WebhookPollInterval,
+		maxAttempts:  cfg.WebhookMaxAttempts,
+		backoffBase:  cfg.WebhookBackoffBase,
+	}
+}
+
+// Run обрабатывает очередь до отмены контекста
+func (d *Dispatcher) Run(ctx context.Context) {
+	log.Println("Start webhook dispatcher...")
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			log.Println("Webhook dispatcher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context) {
+	// доставка не должна повторяться, пока идёт текущая попытка
+	lease := d.client.Timeout + d.pollInterval
+
+	deliveries, err := d.store.ClaimWebhookDeliveries(batchSize, lease)
+	if err != nil {
+		log.Println("claim webhook deliveries error:", err)
+		return
+	}
+
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err := d.send(ctx, delivery); err != nil {
+			attempt := delivery.Attempts + 1
+			dead := attempt >= d.maxAttempts
+			log.Printf("webhook delivery %s attempt %d failed: %s\n", delivery.ID, attempt, err)
+
+			err = d.store.MarkWebhookFailed(delivery.ID, err.Error(), time.Now().Add(d.backoff(attempt)), dead)
+			if err != nil {
+				log.Println("mark webhook failed error:", err)
+			}
+			continue
+		}
+
+		if err := d.store.MarkWebhookDelivered(delivery.ID); err != nil {
+			log.Println("mark webhook delivered error:", err)
+		}
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return fmt.Errorf("creating request error: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json;charset=utf-8")
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Delivery", delivery.ID.String())
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return nil
+}
+
+// backoff возвращает задержку перед следующей попыткой: base * 2^(attempt-1)
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.backoffBase
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return delay
+}
@@ -0,0 +1,243 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"subscribe_service/internal/config"
+	"subscribe_service/internal/entity"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeStore хранит доставки в памяти и выдаёт все неотправленные при каждом опросе
+type fakeStore struct {
+	mu         sync.Mutex
+	deliveries []*entity.WebhookDelivery
+	dead       map[uuid.UUID]bool
+}
+
+func newFakeStore(deliveries ...*entity.WebhookDelivery) *fakeStore {
+	return &fakeStore{deliveries: deliveries, dead: map[uuid.UUID]bool{}}
+}
+
+func (s *fakeStore) ClaimWebhookDeliveries(limit int, lease time.Duration) ([]*entity.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var claimed []*entity.WebhookDelivery
+	for _, d := range s.deliveries {
+		if d.Status == entity.DeliveryPending && len(claimed) < limit {
+			copied := *d
+			claimed = append(claimed, &copied)
+		}
+	}
+	return claimed, nil
+}
+
+func (s *fakeStore) MarkWebhookDelivered(id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d := s.find(id)
+	d.Attempts++
+	d.Status = entity.DeliveryDelivered
+	return nil
+}
+
+func (s *fakeStore) MarkWebhookFailed(id uuid.UUID, lastError string, nextAttemptAt time.Time, dead bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d := s.find(id)
+	d.Attempts++
+	d.LastError = &lastError
+	d.NextAttemptAt = nextAttemptAt
+	if dead {
+		d.Status = entity.DeliveryDead
+		s.dead[id] = true
+	}
+	return nil
+}
+
+func (s *fakeStore) find(id uuid.UUID) *entity.WebhookDelivery {
+	for _, d := range s.deliveries {
+		if d.ID == id {
+			return d
+		}
+	}
+	panic("unknown delivery " + id.String())
+}
+
+func newTestDispatcher(store Store, maxAttempts int, backoffBase time.Duration) *Dispatcher {
+	return NewDispatcher(&config.Config{
+		WebhookPollInterval: time.Second,
+		WebhookTimeout:      5 * time.Second,
+		WebhookMaxAttempts:  maxAttempts,
+		WebhookBackoffBase:  backoffBase,
+	}, store)
+}
+
+func newTestDelivery(url string) *entity.WebhookDelivery {
+	return &entity.WebhookDelivery{
+		ID:        uuid.New(),
+		URL:       url,
+		Secret:    "top-secret",
+		EventType: "subscription.created",
+		Payload:   []byte(`{"type":"subscription.created"}`),
+		Status:    entity.DeliveryPending,
+	}
+}
+
+func TestDispatchSignsBody(t *testing.T) {
+	type received struct {
+		body      []byte
+		signature string
+		event     string
+		delivery  string
+	}
+	got := make(chan received, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- received{
+			body:      body,
+			signature: r.Header.Get(SignatureHeader),
+			event:     r.Header.Get("X-Webhook-Event"),
+			delivery:  r.Header.Get("X-Webhook-Delivery"),
+		}
+	}))
+	defer srv.Close()
+
+	delivery := newTestDelivery(srv.URL)
+	store := newFakeStore(delivery)
+	newTestDispatcher(store, 3, time.Minute).dispatch(context.Background())
+
+	req := <-got
+	if string(req.body) != string(delivery.Payload) {
+		t.Fatalf("body = %s, want %s", req.body, delivery.Payload)
+	}
+	if want := Sign(delivery.Secret, delivery.Payload); req.signature != want {
+		t.Fatalf("signature = %q, want %q", req.signature, want)
+	}
+	if !Verify(delivery.Secret, req.body, req.signature) {
+		t.Fatal("signature does not verify with the endpoint secret")
+	}
+	if Verify("other-secret", req.body, req.signature) {
+		t.Fatal("signature verifies with a foreign secret")
+	}
+	if req.event != delivery.EventType || req.delivery != delivery.ID.String() {
+		t.Fatalf("headers = %q, %q", req.event, req.delivery)
+	}
+	if delivery.Status != entity.DeliveryDelivered {
+		t.Fatalf("status = %s, want delivered", delivery.Status)
+	}
+}
+
+func TestVerifyRejectsMalformedSignature(t *testing.T) {
+	body := []byte("payload")
+	sig := Sign("secret", body)
+
+	for _, s := range []string{"", sig[len(signaturePrefix):], "sha1=" + sig[len(signaturePrefix):], sig + "00"} {
+		if Verify("secret", body, s) {
+			t.Errorf("Verify(%q) = true", s)
+		}
+	}
+	if Verify("secret", []byte("tampered"), sig) {
+		t.Error("signature verifies for a modified body")
+	}
+}
+
+func TestDispatchRetriesWithBackoff(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	delivery := newTestDelivery(srv.URL)
+	store := newFakeStore(delivery)
+	d := newTestDispatcher(store, 3, time.Minute)
+
+	for attempt, want := range []time.Duration{time.Minute, 2 * time.Minute} {
+		before := time.Now()
+		d.dispatch(context.Background())
+
+		if delivery.Attempts != attempt+1 {
+			t.Fatalf("attempts = %d, want %d", delivery.Attempts, attempt+1)
+		}
+		if delivery.Status != entity.DeliveryPending {
+			t.Fatalf("attempt %d: status = %s, want pending", attempt+1, delivery.Status)
+		}
+		delay := delivery.NextAttemptAt.Sub(before)
+		if delay < want || delay > want+time.Second {
+			t.Fatalf("attempt %d: next attempt in %s, want %s", attempt+1, delay, want)
+		}
+		if delivery.LastError == nil || *delivery.LastError == "" {
+			t.Fatalf("attempt %d: last error is not recorded", attempt+1)
+		}
+	}
+
+	d.dispatch(context.Background())
+	if !store.dead[delivery.ID] {
+		t.Fatal("delivery is not dead after max attempts")
+	}
+
+	// мёртвая доставка больше не отправляется
+	d.dispatch(context.Background())
+	if calls != 3 {
+		t.Fatalf("calls = %d, want 3", calls)
+	}
+}
+
+func TestDispatchSucceedsAfterFailure(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 1 {
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	delivery := newTestDelivery(srv.URL)
+	store := newFakeStore(delivery)
+	d := newTestDispatcher(store, 3, time.Minute)
+
+	d.dispatch(context.Background())
+	d.dispatch(context.Background())
+
+	if delivery.Status != entity.DeliveryDelivered || delivery.Attempts != 2 {
+		t.Fatalf("status = %s, attempts = %d, want delivered after 2", delivery.Status, delivery.Attempts)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	d := &Dispatcher{backoffBase: 30 * time.Second}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{6, 16 * time.Minute},
+		{20, maxBackoff},
+	}
+	for _, tt := range tests {
+		if got := d.backoff(tt.attempt); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package webhook
+
+import (
+	"context"
+	"subscribe_service/internal/entity"
+)
+
+type EventStore interface {
+	EnqueueEvent(event *entity.Event) error
+}
+
+// Publisher ставит события из outbox в очередь доставки вебхуков
+type Publisher struct {
+	store EventStore
+}
+
+func NewPublisher(store EventStore) *Publisher {
+	return &Publisher{store: store}
+}
+
+func (p *Publisher) Publish(_ context.Context, event *entity.Event) error {
+	return p.store.EnqueueEvent(event)
+}
@@ -1,24 +1,94 @@
 package run
 
 import (
+	"context"
+	"log"
+	"subscribe_service/internal/cache"
 	"subscribe_service/internal/config"
 	"subscribe_service/internal/controller"
+	"subscribe_service/internal/outbox"
+	"subscribe_service/internal/reminder"
+	repository "subscribe_service/internal/repository/postgres"
+	"subscribe_service/internal/rollup"
 	"subscribe_service/internal/server"
+	"subscribe_service/internal/service"
+	"subscribe_service/internal/trial"
+	"subscribe_service/internal/webhook"
+	"sync"
 )
 
 type app struct {
 	cfg        *config.Config
 	httpServer *server.Server
+	workers    []worker
+}
+
+// worker - фоновая задача, работающая до отмены контекста
+type worker interface {
+	Run(ctx context.Context)
 }
 
 func NewApp(cfg *config.Config) *app {
-	c := controller.NewController(cfg)
+	// без постоянного секрета выданные ссылки на календари перестали бы
+	// работать после перезапуска
+	if cfg.CalendarSecret == "" {
+		log.Fatal("CALENDAR_SECRET is not set")
+	}
+
+	repo := repository.NewRepository(cfg)
+	// чтение перед записью всегда идёт в основную базу, остальное чтение - в реплики
+	primary := service.NewService(cfg, repo.Primary(), repo.Primary())
+	var svc controller.SubscriptionService = service.NewService(cfg, repo.Primary(), repo)
+	if cfg.CacheSize > 0 {
+		svc = cache.NewService(svc, cache.NewLRU(cfg.CacheSize, cfg.CacheTTL))
+	}
+	c := controller.NewController(svc, primary)
+
+	publisher, err := outbox.NewPublisher(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// вебхуки получают события из outbox наравне с настроенным публикатором
+	publisher = outbox.MultiPublisher{webhook.NewPublisher(repo), publisher}
+
+	notifier, err := reminder.NewNotifier(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	reminders, err := reminder.NewScheduler(cfg, repo, notifier)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	return &app{
 		cfg:        cfg,
 		httpServer: server.NewServer(cfg, c),
+		workers: []worker{
+			outbox.NewDispatcher(cfg, repo, publisher),
+			webhook.NewDispatcher(cfg, repo),
+			reminders,
+			trial.NewConverter(cfg, repo),
+			rollup.NewRefresher(cfg, repo),
+			repository.NewReplicaChecker(cfg, repo),
+		},
 	}
 }
 
 func (a *app) Run() {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+
+	for _, w := range a.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.Run(ctx)
+		}()
+	}
+
 	a.httpServer.Serve()
+
+	// фоновые задачи останавливаются после HTTP-сервера
+	cancel()
+	wg.Wait()
 }